@@ -0,0 +1,122 @@
+// Copyright 2018 iriri. All rights reserved. Use of this source code is
+// governed by a BSD-style license which can be found in the LICENSE file.
+
+package flag
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrAmbiguousFlag is wrapped by ParseError when an abbreviated long flag
+// matches more than one flag.
+var ErrAmbiguousFlag = errors.New("ambiguous flag")
+
+// SetAbbreviations enables or disables abbreviated long flags for f and its
+// subcommands. When enabled, a long flag may be given as any prefix of its
+// long variant that is not a prefix of any other, so --verb is the same as
+// --verbose unless --verbatim is also defined. An exact match always takes
+// precedence. Abbreviations are disabled by default.
+func (f *FlagSet) SetAbbreviations(enabled bool) {
+	f.abbrev = enabled
+}
+
+// SetAbbreviations enables or disables abbreviated long flags for
+// CommandLine. See FlagSet.SetAbbreviations.
+func SetAbbreviations(enabled bool) {
+	CommandLine.SetAbbreviations(enabled)
+}
+
+func (f *FlagSet) abbrevEnabled() bool {
+	for ; f != nil; f = f.parent {
+		if f.abbrev {
+			return true
+		}
+	}
+	return false
+}
+
+// longTarget is what a long variant refers to: a flag or its negation.
+type longTarget struct {
+	fl  *Flag
+	neg bool
+}
+
+// longNames returns every long variant that can be given to f, including
+// aliases, negations, and the built-in flags, along with what they refer to.
+func (f *FlagSet) longNames() map[string]longTarget {
+	names := make(map[string]longTarget)
+	for c := f; c != nil; c = c.parent {
+		for s, fl := range c.longFlags {
+			if _, ok := names[s]; !ok {
+				names[s] = longTarget{fl, false}
+			}
+		}
+	}
+	for s, t := range names {
+		if _, ok := names["no-"+s]; !ok && t.fl.negatable() {
+			names["no-"+s] = longTarget{t.fl, true}
+		}
+	}
+	for _, fl := range f.builtinFlags() {
+		if fl.Long != "" {
+			names[fl.Long] = longTarget{fl, false}
+		}
+	}
+	return names
+}
+
+// expandLong returns the long variant that s abbreviates if abbreviations are
+// enabled and s is neither empty nor a long variant itself, or s otherwise.
+func (f *FlagSet) expandLong(s string) (string, error) {
+	if s == "" || !f.abbrevEnabled() {
+		return s, nil
+	}
+	names := f.longNames()
+	if _, ok := names[s]; ok {
+		return s, nil
+	}
+	var matches []string
+	targets := make(map[longTarget]bool)
+	for name, t := range names {
+		if strings.HasPrefix(name, s) {
+			matches = append(matches, name)
+			targets[t] = true
+		}
+	}
+	sort.Strings(matches)
+	switch {
+	case len(matches) == 0:
+		return s, nil
+	case len(targets) == 1:
+		return matches[0], nil
+	}
+	for i, m := range matches {
+		matches[i] = f.getSyntax().longPrefix() + m
+	}
+	return "", fmt.Errorf(
+		"%w: could be %s", ErrAmbiguousFlag, strings.Join(matches, ", "))
+}
+
+// unknownLong returns the error for the unknown long flag s, which suggests
+// the closest listed long variant if any are close enough.
+func (f *FlagSet) unknownLong(s string) error {
+	var names []string
+	for name, t := range f.longNames() {
+		variant := name
+		if t.neg {
+			variant = name[len("no-"):]
+		}
+		if t.fl.listed() && t.fl.aliasListed(variant) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if m := suggest(s, names); m != "" {
+		return fmt.Errorf("%w (did you mean %s%s?)",
+			ErrUnknownFlag, f.getSyntax().longPrefix(), m)
+	}
+	return ErrUnknownFlag
+}
@@ -0,0 +1,75 @@
+package flag
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAbbreviations(t *testing.T) {
+	var verbose, verbatim, color bool
+	var out string
+	f := NewFlagSet("test", ContinueOnError)
+	f.Bool(&verbose, 'v', "verbose", false, "")
+	f.Bool(&color, 0, "color", false, "").Alias("colour")
+	f.String(&out, 'o', "output", "", "")
+	f.Version("1.0")
+
+	if _, err := f.Parse([]string{"--verb"}); !errors.Is(err, ErrUnknownFlag) {
+		t.Error(err)
+	}
+	f.SetAbbreviations(true)
+	_, err := f.Parse([]string{"--verb", "--out=x", "--col", "--no-col"})
+	if err != nil || !verbose || out != "x" || color {
+		t.Error(err)
+	}
+	_, err = f.Parse([]string{"--ver"})
+	var perr *ParseError
+	if !errors.As(err, &perr) || !errors.Is(err, ErrAmbiguousFlag) ||
+		perr.Name != "--ver" || err.Error() !=
+		"--ver: ambiguous flag: could be --verbose, --version" {
+		t.Error(err)
+	}
+
+	var only string
+	g := NewFlagSet("test", ContinueOnError)
+	g.String(&only, 0, "only", "", "")
+	g.SetHelp(false)
+	g.SetAbbreviations(true)
+	if _, err = g.Parse([]string{"--=x"}); !errors.Is(err, ErrUnknownFlag) ||
+		only != "" {
+		t.Error(err, only)
+	}
+
+	c := f.Command("sub", "", nil)
+	c.Bool(&verbatim, 0, "verbatim", false, "")
+	if _, err = c.Parse([]string{"--verbo", "--verba"}); err != nil ||
+		!verbatim {
+		t.Error(err)
+	}
+	if _, err = c.Parse([]string{"--verb"}); !errors.Is(err, ErrAmbiguousFlag) {
+		t.Error(err)
+	}
+}
+
+func TestSuggestFlag(t *testing.T) {
+	var verbose bool
+	var n int
+	f := NewFlagSet("test", ContinueOnError)
+	f.Bool(&verbose, 'v', "verbose", false, "")
+	f.Int(&n, 0, "secret", 0, "").Hidden()
+	for _, c := range []struct {
+		arg, err string
+	}{
+		{"--verbsoe", "--verbsoe: unknown flag (did you mean --verbose?)"},
+		{"--no-verbos", "--no-verbos: unknown flag (did you mean " +
+			"--no-verbose?)"},
+		{"--hepl", "--hepl: unknown flag (did you mean --help?)"},
+		{"--secrt", "--secrt: unknown flag"},
+		{"--xyzzy", "--xyzzy: unknown flag"},
+	} {
+		_, err := f.Parse([]string{c.arg})
+		if !errors.Is(err, ErrUnknownFlag) || err.Error() != c.err {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2018 iriri. All rights reserved. Use of this source code is
+// governed by a BSD-style license which can be found in the LICENSE file.
+
+package flag
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Alias adds alternative names for fl and returns fl. A name that is a single
+// character is a short variant and any other name is a long variant. Aliases
+// are parsed the same as the names fl was defined with and are listed with
+// them in the usage text. If an alias is already in use the error is handled
+// the same as when defining a flag fails.
+func (fl *Flag) Alias(names ...string) *Flag {
+	f := fl.set
+	for _, s := range names {
+		var err error
+		if utf8.RuneCountInString(s) == 1 {
+			r, _ := utf8.DecodeRuneInString(s)
+			if _, ok := f.shortFlags[r]; ok {
+				err = &DefineError{"-" + s, ErrDuplicateFlag}
+			} else {
+				f.shortFlags[r] = fl
+				fl.shorts = append(fl.shorts, r)
+			}
+		} else if s != "" {
+			if _, ok := f.longFlags[s]; ok {
+				err = &DefineError{"--" + s, ErrDuplicateFlag}
+			} else {
+				f.longFlags[s] = fl
+				fl.longs = append(fl.longs, s)
+			}
+		}
+		if err != nil {
+			if f.err == nil {
+				f.err = err
+			}
+			f.fail(err)
+		}
+	}
+	return fl
+}
+
+// Hidden leaves fl out of the usage text and shell completion and returns fl.
+// It is still parsed as usual.
+func (fl *Flag) Hidden() *Flag {
+	fl.hidden = true
+	return fl
+}
+
+// Deprecated marks fl as deprecated and returns fl. A deprecated flag is
+// parsed as usual but is left out of the usage text and shell completion, and
+// the first time it is set a warning followed by msg is written to the output
+// of its flag set. The message should name the replacement, if there is one,
+// e.g. "use --output instead".
+func (fl *Flag) Deprecated(msg string) *Flag {
+	fl.deprecated = msg
+	return fl
+}
+
+// DeprecatedAlias adds name as an alternative name for fl, the same as Alias,
+// but marks the alias alone as deprecated and returns fl. This suits a flag
+// that has been renamed: the old name is still parsed as fl but is left out of
+// the usage text and shell completion, and the first time fl is given by it a
+// warning followed by msg is written to the output of the flag set.
+func (fl *Flag) DeprecatedAlias(name, msg string) *Flag {
+	fl.Alias(name)
+	if fl.depAliases == nil {
+		fl.depAliases = make(map[string]string)
+	}
+	fl.depAliases[name] = msg
+	return fl
+}
+
+// listed reports whether fl appears in the usage text and shell completion.
+func (fl *Flag) listed() bool {
+	return !fl.hidden && fl.deprecated == ""
+}
+
+// aliasListed reports whether the variant s of fl appears in the usage text
+// and shell completion.
+func (fl *Flag) aliasListed(s string) bool {
+	_, ok := fl.depAliases[s]
+	return !ok
+}
+
+// warnDeprecated writes the deprecation warning for fl, which was given as
+// variant, if it or the variant is deprecated and has not been warned about
+// yet.
+func (fl *Flag) warnDeprecated(variant string) {
+	msg, ok := fl.depAliases[variant]
+	key := variant
+	if !ok {
+		msg, key = fl.deprecated, ""
+	}
+	if msg == "" || fl.warned[key] || fl.set == nil {
+		return
+	}
+	if fl.warned == nil {
+		fl.warned = make(map[string]bool)
+	}
+	fl.warned[key] = true
+	name := fl.name()
+	if variant != "" {
+		syn := fl.set.getSyntax()
+		if _, ok := singleRune(variant); ok {
+			name = syn.shortPrefix() + variant
+		} else {
+			name = syn.longPrefix() + variant
+		}
+	}
+	fmt.Fprintf(fl.set.Output(), "warning: %s is deprecated: %s\n", name, msg)
+}
@@ -0,0 +1,91 @@
+package flag
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAlias(t *testing.T) {
+	var out string
+	var color bool
+	f := NewFlagSet("test", ContinueOnError)
+	f.String(&out, 'o', "output", "", "write to `FILE`").Alias("O", "out")
+	f.Bool(&color, 0, "color", false, "").Alias("colour")
+	_, err := f.Parse([]string{"-O", "a", "--no-colour"})
+	if err != nil || out != "a" || color {
+		t.Error(err)
+	}
+	if _, err = f.Parse([]string{"--out=b", "--colour"}); err != nil ||
+		out != "b" || !color || f.Lookup("out") != f.Lookup("o") {
+		t.Error(err)
+	}
+
+	var b bytes.Buffer
+	f.WriteUsage(&b)
+	for _, s := range []string{
+		"  -o, -O, --output, --out=FILE     write to FILE\n",
+		"      --[no-]color, --[no-]colour\n",
+	} {
+		if !strings.Contains(b.String(), s) {
+			t.Error(b.String())
+		}
+	}
+	if s := completeString(f, "--o"); s !=
+		"--output\twrite to FILE\n--out\twrite to FILE\n:\n" {
+		t.Error(s)
+	}
+
+	var x bool
+	f.Bool(&x, 'x', "", false, "")
+	f.Bool(&x, 0, "xx", false, "").Alias("x", "out")
+	_, err = f.Parse(nil)
+	if !errors.Is(err, ErrDuplicateFlag) || err.Error() !=
+		"-x: flag redefined" {
+		t.Error(err)
+	}
+}
+
+func TestHiddenDeprecated(t *testing.T) {
+	var out string
+	var v bool
+	var n int
+	var b bytes.Buffer
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(&b)
+	f.String(&out, 'o', "output", "", "").Required().
+		DeprecatedAlias("out", "use --output instead").
+		DeprecatedAlias("O", "use -o instead")
+	f.Bool(&v, 'v', "verbose", false, "").Deprecated("it does nothing")
+	f.Int(&n, 0, "debug-level", 0, "").Hidden()
+	_, err := f.Parse([]string{
+		"--out", "a", "--out=b", "-O", "c", "--output", "d", "-vv",
+		"--debug-level=2"})
+	if err != nil || out != "d" || !v || n != 2 || !f.Changed("output") {
+		t.Error(err)
+	}
+	if b.String() != "warning: --out is deprecated: use --output instead\n"+
+		"warning: -O is deprecated: use -o instead\n"+
+		"warning: -v is deprecated: it does nothing\n" {
+		t.Error(b.String())
+	}
+
+	b.Reset()
+	f.WriteUsage(&b)
+	if !strings.Contains(b.String(), "-o, --output=STRING") ||
+		strings.Contains(b.String(), "--out ") ||
+		strings.Contains(b.String(), "-O") ||
+		strings.Contains(b.String(), "verbose") ||
+		strings.Contains(b.String(), "--debug-level") {
+		t.Error(b.String())
+	}
+	if s := completeString(f, "-"); strings.Contains(s, "--out\t") ||
+		strings.Contains(s, "-O") || !strings.Contains(s, "--output\t") ||
+		strings.Contains(s, "--debug-level") {
+		t.Error(s)
+	}
+	if err := f.unknownLong("ou"); err != ErrUnknownFlag {
+		t.Error(err)
+	}
+}
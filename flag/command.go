@@ -0,0 +1,154 @@
+// Copyright 2018 iriri. All rights reserved. Use of this source code is
+// governed by a BSD-style license which can be found in the LICENSE file.
+
+package flag
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// The errors wrapped by ParseError when dispatching to a subcommand fails.
+var (
+	ErrUnknownCommand = errors.New("unknown command")
+	ErrMissingCommand = errors.New("missing command")
+)
+
+// Command defines a subcommand of f with the specified name, one line of usage
+// text, and handler and returns the flag set of the subcommand so that flags
+// can be defined on it. Flags defined on f are inherited by the subcommand and
+// may be given either before or after its name. The handler is called by Run
+// with the flag set of the subcommand and its remaining non-flag arguments and
+// may be nil if the subcommand only serves to group further subcommands.
+func (f *FlagSet) Command(
+	name, usage string, run func(*FlagSet, []string) error) *FlagSet {
+	c := NewFlagSet(f.name+" "+name, f.errorHandling)
+	c.parent = f
+	c.usage = usage
+	c.run = run
+	if f.commands == nil {
+		f.commands = make(map[string]*FlagSet)
+	}
+	f.commands[name] = c
+	return c
+}
+
+// Command defines a subcommand on CommandLine. See FlagSet.Command.
+func Command(
+	name, usage string, run func(*FlagSet, []string) error) *FlagSet {
+	return CommandLine.Command(name, usage, run)
+}
+
+// Run parses the flags in args and then dispatches on the remaining
+// arguments. If subcommands have been defined on f the first remaining
+// argument selects one, and Run is called on its flag set with the arguments
+// that follow it. Otherwise the handler of f, if any, is called and its error
+// is returned. An unknown subcommand is reported along with the closest
+// defined subcommand, if any are close enough to be a likely typo. Flag sets
+// with subcommands always stop parsing at the name of the subcommand,
+// regardless of their ordering, and the constraints on their flags are
+// checked along with those of the subcommand before its handler is called.
+func (f *FlagSet) Run(args []string) error {
+	args, err := f.expandArgs(args)
+	if err != nil {
+		return err
+	}
+	rest, err := f.parse(args, len(f.commands) == 0 && f.permute())
+	if err != nil {
+		return err
+	}
+	if len(f.commands) == 0 || len(rest) == 0 && f.run != nil {
+		if err = f.loadConfigFlag(); err != nil {
+			return err
+		}
+		if err = f.checkAllConstraints(); err != nil {
+			return f.fail(err)
+		}
+		if f.run == nil {
+			return nil
+		}
+		return f.run(f, rest)
+	}
+	pos := len(args) - len(rest)
+	if len(rest) == 0 {
+		return f.fail(&ParseError{pos, "", f.name, ErrMissingCommand})
+	}
+	c, ok := f.commands[rest[0]]
+	if !ok {
+		err = ErrUnknownCommand
+		if s := suggest(rest[0], f.commandNames()); s != "" {
+			err = fmt.Errorf("%w (did you mean %s?)", err, s)
+		}
+		return f.fail(&ParseError{pos, rest[0], rest[0], err})
+	}
+	return c.Run(rest[1:])
+}
+
+// Run parses the command line arguments from os.Args[firstFlag:] and
+// dispatches to the subcommands defined on CommandLine. See FlagSet.Run.
+func Run(firstFlag int) error {
+	return CommandLine.Run(os.Args[firstFlag:])
+}
+
+// root returns the flag set that f is a subcommand of, directly or
+// indirectly, or f if it is not a subcommand.
+func (f *FlagSet) root() *FlagSet {
+	for f.parent != nil {
+		f = f.parent
+	}
+	return f
+}
+
+func (f *FlagSet) commandNames() []string {
+	names := make([]string, 0, len(f.commands))
+	for s := range f.commands {
+		names = append(names, s)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// suggest returns the candidate closest to s if it is close enough to
+// plausibly be what was meant, or the empty string otherwise.
+func suggest(s string, candidates []string) string {
+	best, bestDist := "", len(s)/3+1
+	if bestDist > 3 {
+		bestDist = 3
+	}
+	for _, c := range candidates {
+		if d := levenshtein(s, c); d <= bestDist {
+			best, bestDist = c, d-1
+		}
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	row := make([]int, len(t)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		prev := row[0]
+		row[0] = i
+		for j := 1; j <= len(t); j++ {
+			cur := row[j]
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			row[j] = prev + cost
+			if row[j-1]+1 < row[j] {
+				row[j] = row[j-1] + 1
+			}
+			if cur+1 < row[j] {
+				row[j] = cur + 1
+			}
+			prev = cur
+		}
+	}
+	return row[len(t)]
+}
@@ -0,0 +1,66 @@
+package flag
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	var verbose bool
+	var n int64
+	var got []string
+	f := NewFlagSet("tool", ContinueOnError)
+	f.Bool(&verbose, 'v', "verbose", false, "")
+	add := f.Command("add", "add things",
+		func(c *FlagSet, args []string) error {
+			got = args
+			return nil
+		})
+	add.Int64(&n, 'n', "num", 0, "")
+	f.Command("remove", "remove things", nil)
+
+	err := f.Run([]string{"add", "-n", "3", "-v", "a", "b"})
+	if err != nil || !verbose || n != 3 || len(got) != 2 || got[0] != "a" {
+		t.Fail()
+	}
+
+	var perr *ParseError
+	err = f.Run([]string{"-v", "ad"})
+	if !errors.As(err, &perr) || !errors.Is(err, ErrUnknownCommand) ||
+		perr.Pos != 1 || !strings.Contains(err.Error(), "did you mean add") {
+		t.Fail()
+	}
+	err = f.Run([]string{"frobnicate"})
+	if !errors.Is(err, ErrUnknownCommand) ||
+		strings.Contains(err.Error(), "did you mean") {
+		t.Fail()
+	}
+	if !errors.Is(f.Run(nil), ErrMissingCommand) {
+		t.Fail()
+	}
+	if !errors.Is(f.Run([]string{"remove", "-n", "1"}), ErrUnknownFlag) {
+		t.Fail()
+	}
+	verbose, n = false, 0
+	f.SetOrdering(Permute)
+	os.Unsetenv("POSIXLY_CORRECT")
+	err = f.Run([]string{"add", "a", "-v", "b", "-n", "2"})
+	if err != nil || !verbose || n != 2 || strings.Join(got, " ") != "a b" {
+		t.Error(err, got)
+	}
+	add.SetOrdering(RequireOrder)
+	err = f.Run([]string{"add", "a", "-v"})
+	if err != nil || strings.Join(got, " ") != "a -v" {
+		t.Error(err, got)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	if levenshtein("kitten", "sitting") != 3 ||
+		levenshtein("", "abc") != 3 ||
+		levenshtein("flag", "flag") != 0 {
+		t.Fail()
+	}
+}
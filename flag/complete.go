@@ -0,0 +1,373 @@
+// Copyright 2018 iriri. All rights reserved. Use of this source code is
+// governed by a BSD-style license which can be found in the LICENSE file.
+
+package flag
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// ErrComplete is returned by Parse with ContinueOnError when it answers a
+// completion request instead of parsing.
+var ErrComplete = errors.New("completion requested")
+
+// completeCommand is the hidden first argument that causes Parse to print
+// completions instead of parsing if completion is enabled. The completion
+// scripts written by WriteCompletion invoke the program with it followed by
+// the words of the command line up to and including the word being
+// completed. The program prints one candidate per line, optionally followed
+// by a tab and a description, and then a final line of ":files" if the shell
+// should fall back to completing file names or ":" otherwise.
+const completeCommand = "__complete"
+
+// Complete sets the function that supplies candidate values for fl during
+// shell completion and returns fl. The function is called with the text typed
+// so far and should return the values that are valid for the flag; the ones
+// that do not start with the text typed so far are ignored. Flags without a
+// completion function complete their choices, if they were defined with
+// Choice, or file names.
+func (fl *Flag) Complete(fn func(prefix string) []string) *Flag {
+	fl.complete = fn
+	return fl
+}
+
+// CompleteArgs sets the function that supplies candidates for non-flag
+// arguments during shell completion. Without one, non-flag arguments complete
+// file names.
+func (f *FlagSet) CompleteArgs(fn func(prefix string) []string) {
+	f.completeArgs = fn
+}
+
+// CompleteArgs sets the non-flag argument completion function of
+// CommandLine. See FlagSet.CompleteArgs.
+func CompleteArgs(fn func(prefix string) []string) {
+	CommandLine.CompleteArgs(fn)
+}
+
+// complete writes the completions for the last of words, which follow the
+// program name on the command line being completed.
+func (f *FlagSet) complete(w io.Writer, words []string) {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	cur := words[len(words)-1]
+	c := f
+	var pending *Flag
+	dashdash, arg := false, false
+	for _, s := range words[:len(words)-1] {
+		if pending != nil {
+			pending = nil
+			continue
+		}
+		if dashdash {
+			continue
+		}
+		switch c.classify(s) {
+		case endFlag:
+			dashdash = true
+		case longFlag:
+			_, key, _, hasVal := c.splitFlag(s)
+			fl, ok := c.lookupKey(key)
+			if ok && !hasVal && !isBoolFlag(fl.Value) && !fl.optional {
+				pending = fl
+			}
+		case shortFlag:
+			rs := []rune(s[1:])
+			for j, r := range rs {
+				fl, ok := c.lookupShort(r)
+				if !ok || isBoolFlag(fl.Value) {
+					continue
+				}
+				if j == len(rs)-1 && !fl.optional {
+					pending = fl
+				}
+				break
+			}
+		default:
+			if sub, ok := c.commands[s]; ok && !arg {
+				c = sub
+			} else {
+				arg = true
+			}
+		}
+	}
+
+	var cands []string
+	files := false
+	var name, key, val string
+	hasVal := false
+	if !dashdash && c.classify(cur) == longFlag {
+		name, key, val, hasVal = c.splitFlag(cur)
+	}
+	switch {
+	case pending != nil:
+		cands, files = pending.completions(cur, "")
+	case hasVal:
+		if fl, ok := c.lookupKey(key); ok {
+			cands, files = fl.completions(val, cur[:len(name)+1])
+		}
+	case !dashdash && strings.HasPrefix(cur, c.getSyntax().shortPrefix()):
+		cands = c.flagCompletions(cur)
+	default:
+		if !arg {
+			for _, s := range c.commandNames() {
+				if strings.HasPrefix(s, cur) {
+					cands = append(cands, s+"\t"+c.commands[s].usage)
+				}
+			}
+		}
+		if c.completeArgs != nil {
+			cands = append(
+				cands, filterPrefix(c.completeArgs(cur), cur, "")...)
+		} else if len(c.commands) == 0 || arg {
+			files = true
+		}
+	}
+	for _, s := range cands {
+		fmt.Fprintln(w, s)
+	}
+	if files {
+		fmt.Fprintln(w, ":files")
+	} else {
+		fmt.Fprintln(w, ":")
+	}
+}
+
+func (fl *Flag) completions(prefix, lead string) ([]string, bool) {
+	if fl.complete != nil {
+		return filterPrefix(fl.complete(prefix), prefix, lead), false
+	}
+	if c, ok := fl.Value.(*choiceVal); ok {
+		return filterPrefix(c.choices, prefix, lead), false
+	}
+	return nil, true
+}
+
+func filterPrefix(vals []string, prefix, lead string) []string {
+	var cands []string
+	for _, s := range vals {
+		if strings.HasPrefix(s, prefix) {
+			cands = append(cands, lead+s)
+		}
+	}
+	return cands
+}
+
+// lookupKey returns the flag named by key, the name of a flag as returned by
+// splitFlag, allowing for abbreviations.
+func (f *FlagSet) lookupKey(key string) (*Flag, bool) {
+	if r, ok := singleRune(key); ok && f.getSyntax() != GNUSyntax {
+		return f.lookupShort(r)
+	}
+	if long, err := f.expandLong(key); err == nil {
+		key = long
+	}
+	return f.lookupLong(key)
+}
+
+// flagCompletions returns the flags of f and the flag sets of the commands
+// that f is a subcommand of that start with prefix, named in the syntax of f.
+func (f *FlagSet) flagCompletions(prefix string) []string {
+	syn := f.getSyntax()
+	var cands []string
+	seen := make(map[string]bool)
+	add := func(name, usage string) {
+		if !seen[name] && strings.HasPrefix(name, prefix) {
+			seen[name] = true
+			cands = append(cands, name+"\t"+usage)
+		}
+	}
+	for c := f; c != nil; c = c.parent {
+		flags := c.defined
+		if c == f {
+			flags = append(flags[:len(flags):len(flags)],
+				f.builtinFlags()...)
+		}
+		for _, fl := range flags {
+			if !fl.listed() {
+				continue
+			}
+			_, usage := unquoteUsage(fl)
+			var longs []string
+			if fl.Long != "" {
+				longs = append(longs, fl.Long)
+			}
+			for _, l := range fl.longs {
+				if fl.aliasListed(l) {
+					longs = append(longs, l)
+				}
+			}
+			for _, l := range longs {
+				add(syn.longPrefix()+l, usage)
+			}
+			if fl.negatable() &&
+				strings.HasPrefix(prefix, syn.longPrefix()+"no") {
+				for _, l := range longs {
+					add(syn.longPrefix()+"no-"+l, usage)
+				}
+			}
+			var shorts []rune
+			if fl.Short != 0 {
+				shorts = append(shorts, fl.Short)
+			}
+			for _, r := range fl.shorts {
+				if fl.aliasListed(string(r)) {
+					shorts = append(shorts, r)
+				}
+			}
+			for _, r := range shorts {
+				add(syn.shortPrefix()+string(r), usage)
+			}
+		}
+	}
+	return cands
+}
+
+// WriteCompletion writes a script that enables tab completion of the command
+// line of the program in shell, which must be "bash", "zsh", or "fish". The
+// script asks the program itself for completions, so it includes flags and
+// subcommands defined after it was written along with the values supplied by
+// Flag.Complete and FlagSet.CompleteArgs. The program must answer them,
+// which flag sets other than CommandLine only do if enabled by SetCompletion.
+//
+// The scripts are typically installed by having the program write them when
+// given a flag such as --completion and then sourcing or saving the output,
+// e.g. source <(tool --completion bash).
+func (f *FlagSet) WriteCompletion(w io.Writer, shell string) error {
+	t, ok := completionScripts[shell]
+	if !ok {
+		return fmt.Errorf("unsupported shell %q", shell)
+	}
+	for f.parent != nil {
+		f = f.parent
+	}
+	name := filepath.Base(strings.Fields(f.name + " ")[0])
+	ident := strings.Map(func(r rune) rune {
+		if 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' ||
+			'0' <= r && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+	return t.Execute(w, struct{ Name, Ident, Complete string }{
+		name, ident, completeCommand})
+}
+
+// WriteCompletion writes a completion script for CommandLine. See
+// FlagSet.WriteCompletion.
+func WriteCompletion(w io.Writer, shell string) error {
+	return CommandLine.WriteCompletion(w, shell)
+}
+
+var completionScripts = map[string]*template.Template{
+	"bash": template.Must(template.New("bash").Parse(
+		`# bash completion for {{.Name}}
+_{{.Ident}}_complete() {
+	local IFS=$'\n' line directive cur word rest i
+	local -a out words
+	# Rejoin the words that bash split at = and : so that the program sees
+	# the command line as typed, e.g. --color=al rather than --color = al.
+	rest=${COMP_LINE:0:COMP_POINT}
+	for ((i = 0; i <= COMP_CWORD; i++)); do
+		if [[ $i -eq 0 || $rest == [[:space:]]* ]]; then
+			words+=("${COMP_WORDS[i]}")
+		else
+			words[${#words[@]}-1]+=${COMP_WORDS[i]}
+		fi
+		rest=${rest#"${rest%%[![:space:]]*}"}
+		rest=${rest#"${COMP_WORDS[i]}"}
+	done
+	cur=${words[${#words[@]}-1]}
+	word=${COMP_WORDS[COMP_CWORD]}
+	[[ $word == [=:] ]] && word=
+	out=($("${words[0]}" {{.Complete}} "${words[@]:1}" 2>/dev/null))
+	[[ ${#out[@]} -gt 0 ]] || return
+	directive=${out[${#out[@]}-1]}
+	unset 'out[${#out[@]}-1]'
+	COMPREPLY=()
+	for line in "${out[@]}"; do
+		# Bash only replaces the text after the last = or :.
+		line=${line%%$'\t'*}
+		COMPREPLY+=("${line#"${cur%"$word"}"}")
+	done
+	if [[ ${#COMPREPLY[@]} -eq 0 && $directive == :files ]]; then
+		COMPREPLY=($(compgen -f -- "$word"))
+	fi
+}
+complete -F _{{.Ident}}_complete {{.Name}}
+`)),
+	"zsh": template.Must(template.New("zsh").Parse(
+		`#compdef {{.Name}}
+_{{.Ident}}() {
+	local -a out cands
+	local line directive
+	out=("${(@f)$("${words[1]}" {{.Complete}} "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	(( ${#out} )) || return
+	directive=${out[-1]}
+	out=("${(@)out[1,-2]}")
+	for line in "${out[@]}"; do
+		[[ -n $line ]] || continue
+		if [[ $line == *$'\t'* ]]; then
+			cands+=("${${line%%$'\t'*}//:/\\:}:${line#*$'\t'}")
+		else
+			cands+=("${line//:/\\:}")
+		fi
+	done
+	if (( ${#cands} )); then
+		_describe '{{.Name}}' cands
+	elif [[ $directive == :files ]]; then
+		_files
+	fi
+}
+compdef _{{.Ident}} {{.Name}}
+`)),
+	"fish": template.Must(template.New("fish").Parse(
+		`# fish completion for {{.Name}}
+function __{{.Ident}}_complete
+	set -l words (commandline -opc)
+	set -l out ($words[1] {{.Complete}} $words[2..-1] (commandline -ct) 2>/dev/null)
+	or return
+	set -l directive $out[-1]
+	set -e out[-1]
+	if test (count $out) -eq 0 -a "$directive" = :files
+		__fish_complete_path (commandline -ct)
+	else
+		printf '%s\n' $out
+	end
+end
+complete -c {{.Name}} -f -a '(__{{.Ident}}_complete)'
+`)),
+}
+
+// SetCompletion enables or disables answering the completion requests of the
+// scripts written by WriteCompletion. When enabled, Parse and Run write the
+// completions to HelpOutput if the first argument is the one that the scripts
+// give and then, with ExitOnError, exit with status 0. Completion is enabled
+// by default for CommandLine and disabled for other flag sets, and it only
+// applies to flag sets that are not subcommands.
+func (f *FlagSet) SetCompletion(enabled bool) {
+	f.completion = enabled
+}
+
+// SetCompletion enables or disables completion for CommandLine. See
+// FlagSet.SetCompletion.
+func SetCompletion(enabled bool) {
+	CommandLine.SetCompletion(enabled)
+}
+
+// handleComplete answers a completion request from a completion script if
+// args is one and completion is enabled, in which case it reports true along
+// with ErrComplete handled according to the error handling behavior of f.
+func (f *FlagSet) handleComplete(args []string) (bool, error) {
+	if f.parent != nil || !f.completion || len(args) == 0 ||
+		args[0] != completeCommand {
+		return false, nil
+	}
+	f.complete(f.HelpOutput(), args[1:])
+	return true, f.exitBuiltin(ErrComplete)
+}
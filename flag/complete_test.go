@@ -0,0 +1,138 @@
+package flag
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func completeString(f *FlagSet, words ...string) string {
+	var b bytes.Buffer
+	f.complete(&b, words)
+	return b.String()
+}
+
+func TestComplete(t *testing.T) {
+	var b bool
+	var s, color string
+	f := NewFlagSet("/usr/bin/tool", ContinueOnError)
+	f.Bool(&b, 'v', "verbose", false, "be loud")
+	f.String(&color, 'c', "color", "", "when to color").Complete(
+		func(string) []string {
+			return []string{"always", "auto", "never"}
+		})
+	add := f.Command("add", "add things", nil)
+	add.String(&s, 'o', "out", "", "output file")
+	f.Command("amend", "amend things", nil)
+
+	for _, c := range []struct {
+		words []string
+		out   string
+	}{
+		{nil, "add\tadd things\namend\tamend things\n:\n"},
+		{[]string{"ad"}, "add\tadd things\n:\n"},
+		{[]string{"--c"}, "--color\twhen to color\n:\n"},
+		{[]string{"-"}, "--verbose\tbe loud\n-v\tbe loud\n" +
+			"--color\twhen to color\n-c\twhen to color\n" +
+			"--help\tshow this help and exit\n" +
+			"-h\tshow this help and exit\n:\n"},
+		{[]string{"--no"}, "--no-verbose\tbe loud\n:\n"},
+		{[]string{"-c", "a"}, "always\nauto\n:\n"},
+		{[]string{"-vc", "n"}, "never\n:\n"},
+		{[]string{"--color=al"}, "--color=always\n:\n"},
+		{[]string{"add", "--o"}, "--out\toutput file\n:\n"},
+		{[]string{"add", "-o", ""}, ":files\n"},
+		{[]string{"add", "x", ""}, ":files\n"},
+		{[]string{"--", "a"}, "add\tadd things\namend\tamend things\n:\n"},
+	} {
+		if out := completeString(f, c.words...); out != c.out {
+			t.Errorf("%q: %q", c.words, out)
+		}
+	}
+}
+
+func TestWriteCompletion(t *testing.T) {
+	f := NewFlagSet("/usr/bin/my-tool", ContinueOnError)
+	c := f.Command("sub", "", nil)
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		var b bytes.Buffer
+		if err := c.WriteCompletion(&b, shell); err != nil ||
+			!strings.Contains(b.String(), "my_tool") ||
+			!strings.Contains(b.String(), " my-tool") ||
+			!strings.Contains(b.String(), completeCommand) {
+			t.Error(shell)
+		}
+	}
+	if f.WriteCompletion(&bytes.Buffer{}, "csh") == nil {
+		t.Fail()
+	}
+}
+
+func TestSetCompletion(t *testing.T) {
+	var out bytes.Buffer
+	var v bool
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetHelpOutput(&out)
+	f.Bool(&v, 'v', "verbose", false, "be loud")
+	rest, err := f.Parse([]string{completeCommand, "--verb"})
+	if err != nil || len(rest) != 2 || out.Len() != 0 {
+		t.Error(rest, err)
+	}
+	f.SetCompletion(true)
+	rest, err = f.Parse([]string{completeCommand, "--verb"})
+	if err != ErrComplete || rest != nil ||
+		out.String() != "--verbose\tbe loud\n:\n" {
+		t.Error(rest, err, out.String())
+	}
+}
+
+func TestBashCompletion(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip(err)
+	}
+	var color string
+	f := NewFlagSet("tool", ContinueOnError)
+	f.String(&color, 'c', "color", "", "").Complete(func(string) []string {
+		return []string{"always", "auto", "never"}
+	})
+	var script bytes.Buffer
+	if err := f.WriteCompletion(&script, "bash"); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		line  string
+		words []string
+		args  string
+		reply string
+	}{
+		{"tool --color=al", []string{"tool", "--color", "=", "al"},
+			"--color=al", "always"},
+		{"tool --color=", []string{"tool", "--color", "="},
+			"--color=", "always|auto|never"},
+		{"tool -c a", []string{"tool", "-c", "a"}, "-c|a", "always|auto"},
+		{"tool x:y --color=n", []string{"tool", "x", ":", "y", "--color",
+			"=", "n"}, "x:y|--color=n", "never"},
+	} {
+		// The program is stood in for by a function that records its
+		// arguments and answers with the completions of f.
+		out := completeString(f, strings.Split(c.args, "|")...)
+		args := append([]string{"-c", script.String() + `
+tool() { shift; IFS='|'; echo "$*" >"$ARGS"; printf '%s' "$OUT"; }
+COMP_LINE=$1 COMP_POINT=${#1} COMP_WORDS=("${@:2}")
+COMP_CWORD=$((${#COMP_WORDS[@]} - 1))
+_tool_complete
+IFS='|'; echo "${COMPREPLY[*]}"`, "bash", c.line}, c.words...)
+		cmd := exec.Command("bash", args...)
+		path := filepath.Join(t.TempDir(), "args")
+		cmd.Env = append(os.Environ(), "OUT="+out, "ARGS="+path)
+		reply, err := cmd.Output()
+		got, _ := os.ReadFile(path)
+		if err != nil || strings.TrimSpace(string(got)) != c.args ||
+			strings.TrimSpace(string(reply)) != c.reply {
+			t.Errorf("%q: %v %q %q", c.line, err, got, reply)
+		}
+	}
+}
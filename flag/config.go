@@ -0,0 +1,334 @@
+// Copyright 2018 iriri. All rights reserved. Use of this source code is
+// governed by a BSD-style license which can be found in the LICENSE file.
+
+package flag
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrConfigSyntax is wrapped by ConfigError when a config file is malformed.
+var ErrConfigSyntax = errors.New("syntax error")
+
+// ConfigError records an entry in a config file that could not be loaded.
+type ConfigError struct {
+	File string
+	Line int
+	Key  string // the offending key, or "" if the line could not be parsed
+	Err  error
+}
+
+func (e *ConfigError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s:%d: %s: %v", e.File, e.Line, e.Key, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// LoadConfig sets flags from the config file at path. Keys are the long
+// variants of flags, and values are parsed the same as they would be on the
+// command line. Values from the command line and the environment take
+// precedence over values from config files, which take precedence over base
+// values, regardless of whether LoadConfig is called before or after Parse.
+// A key that is given more than once, or whose value is a list, is set once
+// for each value, so repeatable flags accumulate. Keys may name the flags of f
+// and those it inherits; keys that only name flags of other subcommands of the
+// same command are ignored, so that one file can serve every subcommand.
+//
+// Files ending in .json must contain a single object. Its values may be
+// strings, numbers, booleans, or arrays of those. An object value sets the
+// key=value pairs of a StringMap flag or, if no flag has that key, acts as a
+// section whose keys are prefixed with its key and a dash.
+//
+// Other files are read as lines of key = value. Values may be double quoted
+// with Go escapes or single quoted without, and quoted values may be followed
+// by a # comment. Blank lines and lines starting with # or ; are ignored, and
+// a line of [section] prefixes the keys that follow it with section and a
+// dash.
+//
+// If an entry cannot be loaded the error is a *ConfigError and is handled
+// according to the error handling behavior of the flag set.
+func (f *FlagSet) LoadConfig(path string) error {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if strings.EqualFold(filepath.Ext(path), ".json") {
+			err = f.loadJSON(path, data)
+		} else {
+			err = f.loadKeyValue(path, data)
+		}
+	}
+	if err != nil {
+		return f.fail(err)
+	}
+	return nil
+}
+
+// LoadConfig sets flags on CommandLine from a config file. See
+// FlagSet.LoadConfig.
+func LoadConfig(path string) error {
+	return CommandLine.LoadConfig(path)
+}
+
+// ConfigFlag defines a string flag with the specified short and/or long
+// variants and usage text that names a config file. If the flag is given,
+// Parse loads the file with LoadConfig once the rest of the command line has
+// been parsed. Subcommands inherit the flag, and Run loads the file into the
+// flag set of the subcommand that it dispatches to, after parsing its flags.
+func (f *FlagSet) ConfigFlag(short rune, long string, usage string) *Flag {
+	f.configFlag = f.String(&f.configPath, short, long, "", usage)
+	return f.configFlag
+}
+
+// ConfigFlag defines a config file flag on CommandLine. See
+// FlagSet.ConfigFlag.
+func ConfigFlag(short rune, long string, usage string) *Flag {
+	return CommandLine.ConfigFlag(short, long, usage)
+}
+
+// loadConfigFlag loads the file named by the config flag of f or of the
+// nearest parent that defines one, if it was given.
+func (f *FlagSet) loadConfigFlag() error {
+	for c := f; c != nil; c = c.parent {
+		if c.configFlag == nil {
+			continue
+		}
+		if c.configFlag.source == SourceDefault {
+			return nil
+		}
+		return f.LoadConfig(c.configPath)
+	}
+	return nil
+}
+
+// setConfig sets the flag with the long variant key to s unless it was
+// already set by a source that takes precedence.
+func (f *FlagSet) setConfig(key, s string) error {
+	fl, ok := f.lookupLong(key)
+	if !ok {
+		if f.root().definesLong(key) {
+			return nil
+		}
+		return ErrUnknownFlag
+	}
+	if fl.source > SourceConfig {
+		return nil
+	}
+	return setValue(fl, s, SourceConfig, key)
+}
+
+// definesLong reports whether f or any of its subcommands defines a flag with
+// the long variant s.
+func (f *FlagSet) definesLong(s string) bool {
+	if _, ok := f.longFlags[s]; ok {
+		return true
+	}
+	for _, c := range f.commands {
+		if c.definesLong(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *FlagSet) loadKeyValue(path string, data []byte) error {
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	prefix := ""
+	for line := 1; sc.Scan(); line++ {
+		s := strings.TrimSpace(sc.Text())
+		if s == "" || s[0] == '#' || s[0] == ';' {
+			continue
+		}
+		if s[0] == '[' {
+			if s[len(s)-1] != ']' {
+				return &ConfigError{path, line, "", ErrConfigSyntax}
+			}
+			prefix = strings.TrimSpace(s[1 : len(s)-1])
+			if prefix != "" {
+				prefix += "-"
+			}
+			continue
+		}
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return &ConfigError{path, line, "", ErrConfigSyntax}
+		}
+		key := prefix + strings.TrimSpace(s[:eq])
+		val, err := unquote(strings.TrimSpace(s[eq+1:]))
+		if err == nil {
+			err = f.setConfig(key, val)
+		}
+		if err != nil {
+			return &ConfigError{path, line, key, err}
+		}
+	}
+	return sc.Err()
+}
+
+// unquote returns s without its quotes, if it has any. A quoted value may be
+// followed by a comment.
+func unquote(s string) (string, error) {
+	if s == "" || s[0] != '"' && s[0] != '\'' {
+		return s, nil
+	}
+	end := strings.IndexByte(s[1:], s[0]) + 1
+	if s[0] == '"' {
+		q, err := strconv.QuotedPrefix(s)
+		if err != nil {
+			return "", ErrConfigSyntax
+		}
+		end = len(q) - 1
+	}
+	if end == 0 {
+		return "", ErrConfigSyntax
+	}
+	if rest := strings.TrimSpace(s[end+1:]); rest != "" && rest[0] != '#' {
+		return "", ErrConfigSyntax
+	}
+	if s[0] == '\'' {
+		return s[1:end], nil
+	}
+	return strconv.Unquote(s[:end+1])
+}
+
+type jsonLoader struct {
+	f    *FlagSet
+	path string
+	data []byte
+	dec  *json.Decoder
+}
+
+func (l *jsonLoader) line() int {
+	return bytes.Count(l.data[:l.dec.InputOffset()], []byte("\n")) + 1
+}
+
+func (l *jsonLoader) errorf(key string, err error) error {
+	return &ConfigError{l.path, l.line(), key, err}
+}
+
+func (f *FlagSet) loadJSON(path string, data []byte) error {
+	l := &jsonLoader{f, path, data, json.NewDecoder(bytes.NewReader(data))}
+	l.dec.UseNumber()
+	if tok, err := l.dec.Token(); err != nil || tok != json.Delim('{') {
+		return l.errorf("", ErrConfigSyntax)
+	}
+	if err := l.object(""); err != nil {
+		return err
+	}
+	if _, err := l.dec.Token(); err == nil {
+		return l.errorf("", ErrConfigSyntax)
+	}
+	return nil
+}
+
+// object loads the members of an object whose opening brace has been read.
+func (l *jsonLoader) object(prefix string) error {
+	for l.dec.More() {
+		tok, err := l.dec.Token()
+		if err != nil {
+			return l.errorf("", ErrConfigSyntax)
+		}
+		key := prefix + tok.(string)
+		line := l.line()
+		if tok, err = l.dec.Token(); err != nil {
+			return l.errorf(key, ErrConfigSyntax)
+		}
+		var vals []string
+		switch tok {
+		case json.Delim('{'):
+			_, ok := l.f.lookupLong(key)
+			if !ok && !l.f.root().definesLong(key) {
+				if err = l.object(key + "-"); err != nil {
+					return err
+				}
+				continue
+			}
+			vals, err = l.pairs()
+		case json.Delim('['):
+			vals, err = l.array()
+		default:
+			var s string
+			s, err = scalar(tok)
+			vals = []string{s}
+		}
+		for i := 0; err == nil && i < len(vals); i++ {
+			err = l.f.setConfig(key, vals[i])
+		}
+		if err != nil {
+			return &ConfigError{l.path, line, key, err}
+		}
+	}
+	if _, err := l.dec.Token(); err != nil {
+		return l.errorf("", ErrConfigSyntax)
+	}
+	return nil
+}
+
+// pairs reads the members of an object as sorted key=value pairs.
+func (l *jsonLoader) pairs() ([]string, error) {
+	var vals []string
+	for l.dec.More() {
+		key, err := l.dec.Token()
+		if err != nil {
+			return nil, ErrConfigSyntax
+		}
+		tok, err := l.dec.Token()
+		if err != nil {
+			return nil, ErrConfigSyntax
+		}
+		s, err := scalar(tok)
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, key.(string)+"="+s)
+	}
+	if _, err := l.dec.Token(); err != nil {
+		return nil, ErrConfigSyntax
+	}
+	sort.Strings(vals)
+	return vals, nil
+}
+
+// array reads the elements of an array.
+func (l *jsonLoader) array() ([]string, error) {
+	var vals []string
+	for l.dec.More() {
+		tok, err := l.dec.Token()
+		if err != nil {
+			return nil, ErrConfigSyntax
+		}
+		s, err := scalar(tok)
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, s)
+	}
+	if _, err := l.dec.Token(); err != nil {
+		return nil, ErrConfigSyntax
+	}
+	return vals, nil
+}
+
+func scalar(tok interface{}) (string, error) {
+	switch t := tok.(type) {
+	case string:
+		return t, nil
+	case json.Number:
+		return t.String(), nil
+	case bool:
+		return strconv.FormatBool(t), nil
+	}
+	return "", errors.New("unsupported value")
+}
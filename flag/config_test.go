@@ -0,0 +1,135 @@
+package flag
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, name, data string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+type configOpts struct {
+	b    bool
+	i    int64
+	s    string
+	host string
+	l    []string
+	m    map[string]string
+}
+
+func newConfigFlagSet(opt *configOpts) *FlagSet {
+	f := NewFlagSet("test", ContinueOnError)
+	f.Bool(&opt.b, 'b', "bool", false, "")
+	f.Int64(&opt.i, 'i', "int", 1, "")
+	f.String(&opt.s, 's', "str", "base", "")
+	f.String(&opt.host, 0, "db-host", "", "")
+	f.StringSlice(&opt.l, 'l', "list", nil, "")
+	f.StringMap(&opt.m, 0, "label", nil, "")
+	return f
+}
+
+func TestLoadConfig(t *testing.T) {
+	var opt configOpts
+	f := newConfigFlagSet(&opt)
+	path := writeConfig(t, "test.conf", `# comment
+bool = true
+int = 0x20
+str = "quoted # not a comment" # comment
+list = a
+list = 'b'
+
+[db]
+host = localhost
+`)
+	if err := f.LoadConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	_, err := f.Parse([]string{"--int", "5"})
+	if err != nil || !opt.b || opt.i != 5 ||
+		opt.s != "quoted # not a comment" || opt.host != "localhost" ||
+		strings.Join(opt.l, " ") != "a b" {
+		t.Fail()
+	}
+
+	opt = configOpts{}
+	f = newConfigFlagSet(&opt)
+	path = writeConfig(t, "test.json", `{
+	"bool": true,
+	"int": 7,
+	"list": ["x", "y"],
+	"label": {"b": "2", "a": "1"},
+	"db": {"host": "db.example.com"}
+}`)
+	f.SetEnvPrefix("TEST")
+	os.Setenv("TEST_STR", "env")
+	defer os.Unsetenv("TEST_STR")
+	f.ConfigFlag('c', "config", "config file")
+	_, err = f.Parse([]string{"-l", "z", "--config", path})
+	if err != nil || !opt.b || opt.i != 7 || opt.s != "env" ||
+		opt.host != "db.example.com" || strings.Join(opt.l, " ") != "z" ||
+		len(opt.m) != 2 || opt.m["a"] != "1" {
+		t.Fail()
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	for _, c := range []struct {
+		name, data string
+		line       int
+		key        string
+		err        error
+	}{
+		{"a.conf", "bool = true\n\nint = x\n", 3, "int", ErrInvalidValue},
+		{"b.conf", "\nnope = 1\n", 2, "nope", ErrUnknownFlag},
+		{"c.conf", "bool\n", 1, "", ErrConfigSyntax},
+		{"d.conf", "str = \"open\n", 1, "str", ErrConfigSyntax},
+		{"e.json", "{\n\"bool\": true,\n\"int\": \"x\"\n}", 3, "int",
+			ErrInvalidValue},
+		{"f.json", "{\"db\": {\n\"port\": 1}}", 2, "db-port",
+			ErrUnknownFlag},
+		{"g.json", "[]", 1, "", ErrConfigSyntax},
+	} {
+		var opt configOpts
+		f := newConfigFlagSet(&opt)
+		err := f.LoadConfig(writeConfig(t, c.name, c.data))
+		var cerr *ConfigError
+		if !errors.As(err, &cerr) || !errors.Is(err, c.err) ||
+			cerr.Line != c.line || cerr.Key != c.key ||
+			filepath.Base(cerr.File) != c.name {
+			t.Error(c.name, err)
+		}
+	}
+}
+
+func TestConfigCommand(t *testing.T) {
+	path := writeConfig(t, "test.conf", "name = x\ncount = 2\nother = y\n")
+	var name, other string
+	var n int
+	f := NewFlagSet("test", ContinueOnError)
+	f.ConfigFlag('c', "config", "")
+	f.String(&name, 0, "name", "", "")
+	sub := f.Command("sub", "", nil)
+	sub.Int(&n, 'n', "count", 0, "")
+	f.Command("other", "", nil).String(&other, 0, "other", "", "")
+	if err := f.Run([]string{"sub", "--config", path}); err != nil ||
+		name != "x" || n != 2 || other != "" {
+		t.Error(err, name, n, other)
+	}
+
+	name, n = "", 0
+	if err := f.Run([]string{"-c", path, "sub"}); err != nil ||
+		name != "x" || n != 2 {
+		t.Error(err, name, n)
+	}
+	if _, err := f.Parse([]string{"-c", path}); err != nil || name != "x" {
+		t.Error(err, name)
+	}
+}
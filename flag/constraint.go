@@ -0,0 +1,158 @@
+// Copyright 2018 iriri. All rights reserved. Use of this source code is
+// governed by a BSD-style license which can be found in the LICENSE file.
+
+package flag
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The errors wrapped by the violations in a ConstraintError.
+var (
+	ErrRequired   = errors.New("flag is required")
+	ErrDependency = errors.New("requires")
+	ErrExclusive  = errors.New("flags are mutually exclusive")
+)
+
+// ConstraintError records every constraint that was violated once a command
+// line was parsed. Each violation wraps ErrRequired, ErrDependency, or
+// ErrExclusive.
+type ConstraintError struct {
+	Violations []error
+}
+
+func (e *ConstraintError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, err := range e.Violations {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e *ConstraintError) Unwrap() []error {
+	return e.Violations
+}
+
+// Required marks fl as required and returns fl. A required flag must be given
+// on the command line, in the environment, or in a config file.
+func (fl *Flag) Required() *Flag {
+	fl.required = true
+	return fl
+}
+
+// Requires records that if fl is given then each of flags must be as well and
+// returns fl.
+func (fl *Flag) Requires(flags ...*Flag) *Flag {
+	fl.requires = append(fl.requires, flags...)
+	return fl
+}
+
+// Exclusive records that at most one of flags may be given.
+func (f *FlagSet) Exclusive(flags ...*Flag) {
+	f.exclusive = append(f.exclusive, flags)
+}
+
+// Exclusive records that at most one of flags may be given on CommandLine.
+// See FlagSet.Exclusive.
+func Exclusive(flags ...*Flag) {
+	CommandLine.Exclusive(flags...)
+}
+
+// given reports whether fl was set by anything other than its base value.
+func (fl *Flag) given() bool {
+	return fl.source != SourceDefault
+}
+
+// checkConstraints returns a *ConstraintError describing every constraint on
+// the flags of f that is violated, or nil if there are none.
+func (f *FlagSet) checkConstraints() error {
+	var errs []error
+	for _, fl := range f.defined {
+		if fl.required && !fl.given() {
+			errs = append(errs, fmt.Errorf("%s: %w", fl.name(), ErrRequired))
+		}
+	}
+	for _, fl := range f.defined {
+		if !fl.given() {
+			continue
+		}
+		for _, r := range fl.requires {
+			if !r.given() {
+				errs = append(errs, fmt.Errorf(
+					"%s: %w %s", fl.name(), ErrDependency, r.name()))
+			}
+		}
+	}
+	for _, group := range f.exclusive {
+		var given []string
+		for _, fl := range group {
+			if fl.given() {
+				given = append(given, fl.name())
+			}
+		}
+		if len(given) > 1 {
+			errs = append(errs, fmt.Errorf(
+				"%s: %w", strings.Join(given, ", "), ErrExclusive))
+		}
+	}
+	if len(errs) != 0 {
+		return &ConstraintError{errs}
+	}
+	return nil
+}
+
+// checkAllConstraints checks the constraints of f and of the flag sets of
+// the commands that f is a subcommand of.
+func (f *FlagSet) checkAllConstraints() error {
+	var errs []error
+	for c := f; c != nil; c = c.parent {
+		if err := c.checkConstraints(); err != nil {
+			errs = append(errs, err.(*ConstraintError).Violations...)
+		}
+	}
+	if len(errs) != 0 {
+		return &ConstraintError{errs}
+	}
+	return nil
+}
+
+// constraintUsage returns a description of the constraints on fl for its
+// usage text.
+func (f *FlagSet) constraintUsage(fl *Flag) string {
+	var notes []string
+	if fl.required {
+		notes = append(notes, "required")
+	}
+	if len(fl.requires) != 0 {
+		names := make([]string, len(fl.requires))
+		for i, r := range fl.requires {
+			names[i] = r.name()
+		}
+		notes = append(notes, "requires "+strings.Join(names, ", "))
+	}
+	var excl []string
+	for c := f; c != nil; c = c.parent {
+		for _, group := range c.exclusive {
+			for _, g := range group {
+				if g != fl {
+					continue
+				}
+				for _, o := range group {
+					if o != fl {
+						excl = append(excl, o.name())
+					}
+				}
+				break
+			}
+		}
+	}
+	if len(excl) != 0 {
+		notes = append(notes, "conflicts with "+strings.Join(excl, ", "))
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(notes, "; ") + ")"
+}
@@ -0,0 +1,79 @@
+package flag
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newConstraintFlagSet() *FlagSet {
+	var out, key, cert string
+	var json, yaml bool
+	f := NewFlagSet("test", ContinueOnError)
+	f.String(&out, 'o', "out", "", "output file").Required()
+	certFlag := f.String(&cert, 0, "tls-cert", "", "")
+	f.String(&key, 0, "tls-key", "", "").Requires(certFlag)
+	f.Exclusive(
+		f.Bool(&json, 0, "json", false, ""),
+		f.Bool(&yaml, 0, "yaml", false, ""))
+	return f
+}
+
+func TestConstraints(t *testing.T) {
+	f := newConstraintFlagSet()
+	if _, err := f.Parse([]string{"-o", "x", "--json"}); err != nil {
+		t.Error(err)
+	}
+	f = newConstraintFlagSet()
+	_, err := f.Parse([]string{"--tls-key", "k", "--json", "--yaml"})
+	var cerr *ConstraintError
+	if !errors.As(err, &cerr) || len(cerr.Violations) != 3 ||
+		!errors.Is(err, ErrRequired) || !errors.Is(err, ErrDependency) ||
+		!errors.Is(err, ErrExclusive) {
+		t.Fatal(err)
+	}
+	if err.Error() != "--out: flag is required\n"+
+		"--tls-key: requires --tls-cert\n"+
+		"--json, --yaml: flags are mutually exclusive" {
+		t.Error(err)
+	}
+
+	var b bytes.Buffer
+	f.WriteUsage(&b)
+	for _, s := range []string{
+		"output file (required)",
+		"(requires --tls-cert)",
+		"(conflicts with --yaml)",
+	} {
+		if !strings.Contains(b.String(), s) {
+			t.Error(s)
+		}
+	}
+}
+
+func TestCommandConstraints(t *testing.T) {
+	var out string
+	var n int64
+	ran := false
+	newFlagSet := func() *FlagSet {
+		f := NewFlagSet("test", ContinueOnError)
+		f.String(&out, 'o', "out", "", "").Required()
+		c := f.Command("sub", "", func(*FlagSet, []string) error {
+			ran = true
+			return nil
+		})
+		c.Int64(&n, 'n', "", 0, "").Required()
+		return f
+	}
+	err := newFlagSet().Run([]string{"sub", "-n", "1", "-o", "x"})
+	if err != nil || !ran {
+		t.Fail()
+	}
+	ran = false
+	err = newFlagSet().Run([]string{"sub"})
+	var cerr *ConstraintError
+	if !errors.As(err, &cerr) || len(cerr.Violations) != 2 || ran {
+		t.Fail()
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2018 iriri. All rights reserved. Use of this source code is
+// governed by a BSD-style license which can be found in the LICENSE file.
+
+package flag
+
+import (
+	"os"
+	"strings"
+)
+
+// Env sets the name of the environment variable that supplies the value of fl
+// when it is not given on the command line and returns fl. Values from the
+// command line take precedence over values from the environment, which take
+// precedence over the base value.
+func (fl *Flag) Env(name string) *Flag {
+	fl.env = name
+	return fl
+}
+
+// SetEnvPrefix causes every flag with a long variant that has not been given
+// an environment variable with Flag.Env to be backed by one named after the
+// long variant. The name is prefix, an underscore, and the long variant in
+// upper case with every character that is not a letter or digit replaced by
+// an underscore, so with a prefix of "APP" the flag --s-t-r is backed by
+// APP_S_T_R. The prefix is inherited by subcommands. An empty prefix disables
+// this.
+func (f *FlagSet) SetEnvPrefix(prefix string) {
+	f.envPrefix = prefix
+}
+
+// SetEnvPrefix sets the environment variable prefix of CommandLine. See
+// FlagSet.SetEnvPrefix.
+func SetEnvPrefix(prefix string) {
+	CommandLine.SetEnvPrefix(prefix)
+}
+
+func (f *FlagSet) getEnvPrefix() string {
+	for ; f != nil; f = f.parent {
+		if f.envPrefix != "" {
+			return f.envPrefix
+		}
+	}
+	return ""
+}
+
+// envName returns the name of the environment variable backing fl, if any.
+func (fl *Flag) envName() string {
+	if fl.env != "" || fl.Long == "" {
+		return fl.env
+	}
+	prefix := fl.set.getEnvPrefix()
+	if prefix == "" {
+		return ""
+	}
+	return prefix + "_" + strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z':
+			return r - 'a' + 'A'
+		case 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+			return r
+		}
+		return '_'
+	}, fl.Long)
+}
+
+// applyEnv sets the flags of f that were not given on the command line from
+// the environment. An invalid value is reported as a ParseError with a Pos of
+// -1 and the name of the environment variable.
+func (f *FlagSet) applyEnv() error {
+	for _, fl := range f.defined {
+		if fl.source >= SourceEnv {
+			continue
+		}
+		name := fl.envName()
+		if name == "" {
+			continue
+		}
+		s, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setValue(fl, s, SourceEnv, ""); err != nil {
+			return &ParseError{-1, name + "=" + s, name, err}
+		}
+	}
+	return nil
+}
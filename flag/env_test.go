@@ -0,0 +1,73 @@
+package flag
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestEnv(t *testing.T) {
+	var b bool
+	var i int64
+	var s string
+	var l []string
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetEnvPrefix("APP")
+	f.Bool(&b, 'b', "bool", false, "")
+	f.Int64(&i, 'i', "", 1, "").Env("APP_INT")
+	f.String(&s, 0, "s-t-r", "base", "")
+	f.StringSlice(&l, 'l', "list", nil, "")
+	os.Setenv("APP_BOOL", "true")
+	os.Setenv("APP_INT", "0x10")
+	os.Setenv("APP_S_T_R", "env")
+	os.Setenv("APP_LIST", "a")
+	defer func() {
+		for _, s := range []string{"BOOL", "INT", "S_T_R", "LIST"} {
+			os.Unsetenv("APP_" + s)
+		}
+	}()
+	_, err := f.Parse([]string{"--s-t-r", "cmd", "-l", "b"})
+	if err != nil || !b || i != 16 || s != "cmd" ||
+		strings.Join(l, " ") != "b" {
+		t.Fail()
+	}
+
+	var n int64
+	g := NewFlagSet("test", ContinueOnError)
+	g.Int64(&n, 'n', "num", 0, "").Env("APP_INT")
+	_, err = g.Parse(nil)
+	if err != nil || n != 16 {
+		t.Fail()
+	}
+	_, err = g.Parse([]string{"-n", "2", "-n", "3"})
+	if err != nil || n != 3 {
+		t.Fail()
+	}
+
+	os.Setenv("APP_INT", "x")
+	var perr *ParseError
+	h := NewFlagSet("test", ContinueOnError)
+	h.Int64(&n, 'n', "num", 0, "").Env("APP_INT")
+	_, err = h.Parse(nil)
+	if !errors.As(err, &perr) || !errors.Is(err, ErrInvalidValue) ||
+		perr.Pos != -1 || perr.Name != "APP_INT" {
+		t.Fail()
+	}
+}
+
+func TestEnvSliceOverride(t *testing.T) {
+	var l []string
+	f := NewFlagSet("test", ContinueOnError)
+	f.StringSliceSep(&l, 'l', "", ",", []string{"base"}, "").Env("APP_L")
+	os.Setenv("APP_L", "a,b")
+	defer os.Unsetenv("APP_L")
+	if _, err := f.Parse(nil); err != nil ||
+		strings.Join(l, " ") != "a b" {
+		t.Fail()
+	}
+	if _, err := f.Parse([]string{"-l", "c"}); err != nil ||
+		strings.Join(l, " ") != "c" {
+		t.Fail()
+	}
+}
@@ -3,31 +3,129 @@
 
 // Package flag provides a very minimal command line flag parser. Both short
 // flags (-s) and long flags (--long) are supported. Short flags can be chained
-// (-xvzf) and "--" is treated as the end of flags marker. Boolean and integer
-// values have first-class support; strings values are intended to serve as a
-// catch-all for anything else.
+// (-xvzf) and "--" is treated as the end of flags marker. Values may be given
+// as the next argument (-o file, --out file) or attached (-ofile, --out=file).
+// Boolean, integer, floating-point, and duration values have first-class
+// support; string values are intended to serve as a catch-all for anything
+// else, and other types can be supported by implementing Value. Integers are
+// parsed like integer literals in Go, so they may have a base prefix (0x, 0o,
+// 0b) and contain underscores, except that a leading 0 does not make them
+// octal.
+//
+// Flags are defined on a FlagSet. The package-level functions operate on
+// CommandLine, the default set, and parse os.Args.
 package flag
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
-type flagVal interface {
-	set(interface{})
+// Value is the interface to the value of a flag. Set is called with the text
+// given for the flag on the command line and should return an error if the
+// text is malformed. String returns the current value as text.
+//
+// If a Value has an IsBoolFlag() bool method returning true the flag does not
+// take a value and Set is called with "true" whenever the flag is given. Such
+// flags may also be given a value after an equals sign (--long=false), and
+// Set is called with "false" when they are negated (--no-long).
+type Value interface {
+	String() string
+	Set(string) error
+}
+
+type boolFlag interface {
+	Value
+	IsBoolFlag() bool
 }
 
 type boolVal bool
 type int64Val int64
 type stringVal string
+type intVal int
+type uintVal uint
+type uint64Val uint64
+type float64Val float64
+type durationVal time.Duration
+type countVal int
+
+type sliceVal struct {
+	p   *[]string
+	sep string
+	set bool
+}
+
+type mapVal struct {
+	p   *map[string]string
+	set bool
+}
+
+// A Flag is a defined flag. It is returned by the functions that define flags
+// so that it can be configured further.
+type Flag struct {
+	Value    Value
+	Usage    string
+	Long     string // the long variant without dashes, or "" if there is none
+	Short    rune   // the short variant, or 0 if there is none
+	DefValue string // the base value as text
+
+	env      string
+	source   Source
+	set      *FlagSet
+	complete func(string) []string
+	group    string
+	required bool
+	requires []*Flag
+
+	validators []func(string) error
+	shorts     []rune   // short aliases
+	longs      []string // long aliases
+	hidden     bool
+	deprecated string
+	depAliases map[string]string // messages of deprecated aliases
+	warned     map[string]bool   // variants warned about, "" for fl itself
+	optional   bool              // whether the value is optional
+	implicit   string
+}
 
-type flag struct {
-	val   flagVal
-	usage string
-	long  string
-	short rune
+// Source records where the value of a flag came from. Sources with higher
+// values take precedence over those with lower values.
+type Source int
+
+// The sources of flag values, in order of increasing precedence.
+const (
+	SourceDefault     Source = iota // the base value
+	SourceConfig                    // a config file
+	SourceEnv                       // an environment variable
+	SourceCommandLine               // the command line
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceDefault:
+		return "default"
+	case SourceConfig:
+		return "config"
+	case SourceEnv:
+		return "env"
+	case SourceCommandLine:
+		return "command line"
+	}
+	return "Source(" + strconv.Itoa(int(s)) + ")"
+}
+
+// resetter is implemented by values that accumulate, such as slices, so that
+// a source with higher precedence replaces, rather than adds to, what was
+// set by a source with lower precedence.
+type resetter interface {
+	reset()
 }
 
 type flagType uint
@@ -41,98 +139,793 @@ const (
 
 var osExit = os.Exit
 
-func (v *boolVal) set(b interface{}) {
-	*v = boolVal(b.(bool))
+func (v *boolVal) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*v = boolVal(b)
+	return nil
+}
+
+func (v *boolVal) String() string {
+	return strconv.FormatBool(bool(*v))
 }
 
-func (v *int64Val) set(i interface{}) {
-	*v = int64Val(i.(int64))
+func (v *boolVal) IsBoolFlag() bool {
+	return true
 }
 
-func (v *stringVal) set(s interface{}) {
-	*v = stringVal(s.(string))
+// decimal returns s without the leading zeros that strconv would otherwise
+// take as an octal prefix, so that integers are decimal unless they start
+// with 0x, 0o, or 0b.
+func decimal(s string) string {
+	sign := ""
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		sign, s = s[:1], s[1:]
+	}
+	for len(s) > 1 && s[0] == '0' && '0' <= s[1] && s[1] <= '9' {
+		s = s[1:]
+	}
+	return sign + s
+}
+
+func (v *int64Val) Set(s string) error {
+	n, err := strconv.ParseInt(decimal(s), 0, 64)
+	if err != nil {
+		return err
+	}
+	*v = int64Val(n)
+	return nil
+}
+
+func (v *int64Val) String() string {
+	return strconv.FormatInt(int64(*v), 10)
+}
+
+func (v *stringVal) Set(s string) error {
+	*v = stringVal(s)
+	return nil
+}
+
+func (v *stringVal) String() string {
+	return string(*v)
 }
 
-var shortFlags = make(map[rune]flag)
-var longFlags = make(map[string]flag)
+func (v *intVal) Set(s string) error {
+	n, err := strconv.ParseInt(decimal(s), 0, strconv.IntSize)
+	if err != nil {
+		return err
+	}
+	*v = intVal(n)
+	return nil
+}
+
+func (v *intVal) String() string {
+	return strconv.Itoa(int(*v))
+}
 
-func (f flag) printUsageAndDelete() {
-	if f.short != 0 && f.long != "" {
-		fmt.Fprintf(os.Stderr, "    -%c --%s\t%s\n",
-			f.short, f.long, f.usage)
-		delete(shortFlags, f.short)
-		delete(longFlags, f.long)
-	} else if f.short != 0 {
-		fmt.Fprintf(os.Stderr, "    -%c\t\t%s\n",
-			f.short, f.usage)
-		delete(shortFlags, f.short)
-	} else if f.long != "" {
-		fmt.Fprintf(os.Stderr, "    --%s\t%s\n",
-			f.long, f.usage)
-		delete(longFlags, f.long)
+func (v *uintVal) Set(s string) error {
+	n, err := strconv.ParseUint(decimal(s), 0, strconv.IntSize)
+	if err != nil {
+		return err
 	}
+	*v = uintVal(n)
+	return nil
 }
 
-// Bool defines a bool flag with the specified short and/or long variants, base
-// value, and usage text. The argument val points to where the value is stored.
-func Bool(val *bool, short rune, long string, base bool, usage string) {
+func (v *uintVal) String() string {
+	return strconv.FormatUint(uint64(*v), 10)
+}
+
+func (v *uint64Val) Set(s string) error {
+	n, err := strconv.ParseUint(decimal(s), 0, 64)
+	if err != nil {
+		return err
+	}
+	*v = uint64Val(n)
+	return nil
+}
+
+func (v *uint64Val) String() string {
+	return strconv.FormatUint(uint64(*v), 10)
+}
+
+func (v *float64Val) Set(s string) error {
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*v = float64Val(n)
+	return nil
+}
+
+func (v *float64Val) String() string {
+	return strconv.FormatFloat(float64(*v), 'g', -1, 64)
+}
+
+func (v *durationVal) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*v = durationVal(d)
+	return nil
+}
+
+func (v *durationVal) String() string {
+	return time.Duration(*v).String()
+}
+
+func (v *countVal) Set(s string) error {
+	switch s {
+	case "true":
+		*v++
+		return nil
+	case "false":
+		*v = 0
+		return nil
+	}
+	n, err := strconv.ParseInt(decimal(s), 0, strconv.IntSize)
+	if err != nil {
+		return err
+	}
+	*v = countVal(n)
+	return nil
+}
+
+func (v *countVal) String() string {
+	return strconv.Itoa(int(*v))
+}
+
+func (v *countVal) IsBoolFlag() bool {
+	return true
+}
+
+func (v *countVal) reset() {
+	*v = 0
+}
+
+// Set appends s, or the fields of s if v has a separator, to the slice. The
+// first call replaces the base value instead.
+func (v *sliceVal) Set(s string) error {
+	if !v.set {
+		*v.p = nil
+		v.set = true
+	}
+	if v.sep == "" {
+		*v.p = append(*v.p, s)
+	} else {
+		*v.p = append(*v.p, strings.Split(s, v.sep)...)
+	}
+	return nil
+}
+
+func (v *sliceVal) reset() {
+	v.set = false
+}
+
+func (v *sliceVal) String() string {
+	sep := v.sep
+	if sep == "" {
+		sep = ","
+	}
+	return strings.Join(*v.p, sep)
+}
+
+// Set adds the key=value pair s to the map. The first call replaces the base
+// value instead.
+func (v *mapVal) Set(s string) error {
+	eq := strings.IndexByte(s, '=')
+	if eq < 0 {
+		return errors.New("expected key=value")
+	}
+	if eq == 0 {
+		return errors.New("empty key")
+	}
+	if !v.set {
+		*v.p = make(map[string]string)
+		v.set = true
+	}
+	(*v.p)[s[:eq]] = s[eq+1:]
+	return nil
+}
+
+func (v *mapVal) reset() {
+	v.set = false
+}
+
+func (v *mapVal) String() string {
+	keys := make([]string, 0, len(*v.p))
+	for k := range *v.p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		keys[i] = k + "=" + (*v.p)[k]
+	}
+	return strings.Join(keys, ",")
+}
+
+func isBoolFlag(v Value) bool {
+	b, ok := v.(boolFlag)
+	return ok && b.IsBoolFlag()
+}
+
+// ErrorHandling defines how a FlagSet behaves when defining or parsing a flag
+// fails.
+type ErrorHandling int
+
+const (
+	// ContinueOnError causes Parse to return the error.
+	ContinueOnError ErrorHandling = iota
+	// ExitOnError causes the error and usage text to be printed and the
+	// program to exit with status 1.
+	ExitOnError
+	// PanicOnError causes a panic with the error.
+	PanicOnError
+)
+
+// The errors wrapped by ParseError and DefineError. They can be tested for
+// with errors.Is.
+var (
+	ErrUnknownFlag     = errors.New("unknown flag")
+	ErrMissingValue    = errors.New("missing value")
+	ErrUnexpectedValue = errors.New("flag does not take a value")
+	ErrInvalidValue    = errors.New("invalid value")
+	ErrDuplicateFlag   = errors.New("flag redefined")
+	ErrInvalidName     = errors.New(
+		"single character flags cannot be declared as long")
+)
+
+// ParseError records a command line argument that could not be parsed.
+type ParseError struct {
+	Pos  int    // index of Arg in the arguments to Parse, or -1 if none
+	Arg  string // the offending argument
+	Name string // the offending flag, e.g. "-i" or "--int"
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return e.Name + ": " + e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// DefineError records a flag that could not be defined.
+type DefineError struct {
+	Name string // the offending flag, e.g. "-i" or "--int"
+	Err  error
+}
+
+func (e *DefineError) Error() string {
+	return e.Name + ": " + e.Err.Error()
+}
+
+func (e *DefineError) Unwrap() error {
+	return e.Err
+}
+
+func invalidValue(s string, err error) error {
+	if nerr, ok := err.(*strconv.NumError); ok {
+		err = nerr.Err
+	}
+	return fmt.Errorf("%w %q: %v", ErrInvalidValue, s, err)
+}
+
+// Ordering defines how a FlagSet treats flags that follow non-flag arguments.
+type Ordering int
+
+const (
+	// RequireOrder stops parsing at the first non-flag argument, so that
+	// "tool file -v" has two non-flag arguments. This is what POSIX requires.
+	RequireOrder Ordering = iota
+	// Permute parses flags from anywhere on the command line, so that
+	// "tool file -v" has one flag and one non-flag argument, and moves the
+	// non-flag arguments to the end of the arguments in their original
+	// order. Flags are never parsed after "--". If the POSIXLY_CORRECT
+	// environment variable is set Permute behaves like RequireOrder.
+	Permute
+)
+
+// A FlagSet is a set of defined flags. Each FlagSet is independent of every
+// other, so libraries, tests, and subcommands can each own one without
+// clobbering one another's flags.
+type FlagSet struct {
+	name          string
+	errorHandling ErrorHandling
+	ordering      Ordering
+	orderingSet   bool
+	output        io.Writer
+	helpOutput    io.Writer
+	err           error
+	shortFlags    map[rune]*Flag
+	longFlags     map[string]*Flag
+	defined       []*Flag
+	envPrefix     string
+	configFlag    *Flag
+	configPath    string
+	completeArgs  func(string) []string
+	usageLine     string
+	description   string
+	footer        string
+	group         string
+	width         int
+	noHelp        bool
+	abbrev        bool
+	responseFiles bool
+	syntax        Syntax
+	syntaxSet     bool
+	negativeArgs  bool
+	completion    bool
+	version       string
+	exclusive     [][]*Flag
+	parent        *FlagSet
+	usage         string
+	run           func(*FlagSet, []string) error
+	commands      map[string]*FlagSet
+}
+
+// CommandLine is the default set of flags used by the package-level
+// functions. Its name is the name of the program, it exits on error, and it
+// answers completion requests.
+var CommandLine = &FlagSet{
+	name:          os.Args[0],
+	errorHandling: ExitOnError,
+	shortFlags:    make(map[rune]*Flag),
+	longFlags:     make(map[string]*Flag),
+	completion:    true,
+}
+
+// NewFlagSet returns a new, empty flag set with the specified name, which is
+// used in usage text, and error handling behavior.
+func NewFlagSet(name string, errorHandling ErrorHandling) *FlagSet {
+	return &FlagSet{
+		name:          name,
+		errorHandling: errorHandling,
+		shortFlags:    make(map[rune]*Flag),
+		longFlags:     make(map[string]*Flag),
+	}
+}
+
+// Name returns the name of the flag set.
+func (f *FlagSet) Name() string {
+	return f.name
+}
+
+// ErrorHandling returns the error handling behavior of the flag set.
+func (f *FlagSet) ErrorHandling() ErrorHandling {
+	return f.errorHandling
+}
+
+// Ordering returns the argument ordering of the flag set, which subcommands
+// inherit from their parent unless they set their own.
+func (f *FlagSet) Ordering() Ordering {
+	for ; f != nil; f = f.parent {
+		if f.orderingSet {
+			return f.ordering
+		}
+	}
+	return RequireOrder
+}
+
+// SetOrdering sets the argument ordering of the flag set and, unless they set
+// their own, its subcommands. The default is RequireOrder.
+func (f *FlagSet) SetOrdering(o Ordering) {
+	f.ordering = o
+	f.orderingSet = true
+}
+
+func (f *FlagSet) permute() bool {
+	if f.Ordering() != Permute {
+		return false
+	}
+	_, ok := os.LookupEnv("POSIXLY_CORRECT")
+	return !ok
+}
+
+// Output returns the destination for usage text and error messages. It is
+// os.Stderr unless changed by SetOutput or, for subcommands, inherited from
+// the parent flag set.
+func (f *FlagSet) Output() io.Writer {
+	if f.output != nil {
+		return f.output
+	}
+	if f.parent != nil {
+		return f.parent.Output()
+	}
+	return os.Stderr
+}
+
+// SetOutput sets the destination for usage text and error messages.
+func (f *FlagSet) SetOutput(w io.Writer) {
+	f.output = w
+}
+
+// fail handles err according to the error handling behavior of the flag set.
+func (f *FlagSet) fail(err error) error {
+	switch f.errorHandling {
+	case ExitOnError:
+		fmt.Fprintln(f.Output(), err)
+		switch err.(type) {
+		case *ParseError, *ConstraintError:
+			f.PrintUsageAndExit()
+		default:
+			osExit(1)
+		}
+	case PanicOnError:
+		panic(err)
+	}
+	return err
+}
+
+// define registers a flag. If the flag cannot be defined the error is handled
+// and the returned flag is not registered with f.
+func (f *FlagSet) define(val Value, short rune, long, usage string) *Flag {
+	var err error
 	if len(long) == 1 {
-		fmt.Fprintf(
-			os.Stderr,
-			"single character flags cannot be declared as long\n")
-		osExit(1)
-		return
+		err = &DefineError{"--" + long, ErrInvalidName}
+	} else if _, ok := f.shortFlags[short]; ok && short != 0 {
+		err = &DefineError{"-" + string(short), ErrDuplicateFlag}
+	} else if _, ok := f.longFlags[long]; ok && long != "" {
+		err = &DefineError{"--" + long, ErrDuplicateFlag}
+	}
+	fl := &Flag{
+		Value:    val,
+		Usage:    usage,
+		Long:     long,
+		Short:    short,
+		DefValue: val.String(),
+		set:      f,
+		group:    f.group,
+	}
+	if err != nil {
+		if f.err == nil {
+			f.err = err
+		}
+		f.fail(err)
+		return fl
 	}
-	f := flag{(*boolVal)(val), usage, long, short}
-	*val = base
 	if short != 0 {
-		shortFlags[short] = f
+		f.shortFlags[short] = fl
 	}
 	if long != "" {
-		longFlags[long] = f
+		f.longFlags[long] = fl
 	}
+	f.defined = append(f.defined, fl)
+	return fl
+}
+
+// Var defines a flag with the specified short and/or long variants and usage
+// text whose value is stored by val. The flag's base value is whatever val
+// holds when Var is called. Var can be used to define flags of types that are
+// not supported by this package. Like the other functions that define flags
+// Var returns the new flag so that it can be configured further.
+//
+// If the flag cannot be defined the error is handled according to the error
+// handling behavior of the flag set; with ContinueOnError it is returned by
+// the next call to Parse.
+func (f *FlagSet) Var(
+	val Value, short rune, long string, usage string) *Flag {
+	return f.define(val, short, long, usage)
+}
+
+// Bool defines a bool flag with the specified short and/or long variants, base
+// value, and usage text. The argument val points to where the value is stored.
+func (f *FlagSet) Bool(
+	val *bool, short rune, long string, base bool, usage string) *Flag {
+	*val = base
+	return f.define((*boolVal)(val), short, long, usage)
 }
 
 // Int64 defines an int64 flag with the specified short and/or long variants,
 // base value, and usage text. The argument val points to where the value is
 // stored.
-func Int64(val *int64, short rune, long string, base int64, usage string) {
-	if len(long) == 1 {
-		fmt.Fprintf(
-			os.Stderr,
-			"single character flags cannot be declared as long\n")
-		osExit(1)
-		return
-	}
-	f := flag{(*int64Val)(val), usage, long, short}
+func (f *FlagSet) Int64(
+	val *int64, short rune, long string, base int64, usage string) *Flag {
 	*val = base
-	if short != 0 {
-		shortFlags[short] = f
-	}
-	if long != "" {
-		longFlags[long] = f
-	}
+	return f.define((*int64Val)(val), short, long, usage)
 }
 
 // String defines a string flag with the specified short and/or long variants,
 // base value, and usage text. The argument val points to where the value is
 // stored.
-func String(val *string, short rune, long string, base string, usage string) {
-	if len(long) == 1 {
-		fmt.Fprintf(
-			os.Stderr,
-			"single character flags cannot be declared as long\n")
-		osExit(1)
-		return
-	}
-	f := flag{(*stringVal)(val), usage, long, short}
+func (f *FlagSet) String(
+	val *string, short rune, long string, base string, usage string) *Flag {
 	*val = base
-	if short != 0 {
-		shortFlags[short] = f
+	return f.define((*stringVal)(val), short, long, usage)
+}
+
+// Int defines an int flag with the specified short and/or long variants, base
+// value, and usage text. The argument val points to where the value is stored.
+func (f *FlagSet) Int(
+	val *int, short rune, long string, base int, usage string) *Flag {
+	*val = base
+	return f.define((*intVal)(val), short, long, usage)
+}
+
+// Uint defines a uint flag with the specified short and/or long variants, base
+// value, and usage text. The argument val points to where the value is stored.
+func (f *FlagSet) Uint(
+	val *uint, short rune, long string, base uint, usage string) *Flag {
+	*val = base
+	return f.define((*uintVal)(val), short, long, usage)
+}
+
+// Uint64 defines a uint64 flag with the specified short and/or long variants,
+// base value, and usage text. The argument val points to where the value is
+// stored.
+func (f *FlagSet) Uint64(
+	val *uint64, short rune, long string, base uint64, usage string) *Flag {
+	*val = base
+	return f.define((*uint64Val)(val), short, long, usage)
+}
+
+// Float64 defines a float64 flag with the specified short and/or long
+// variants, base value, and usage text. The argument val points to where the
+// value is stored.
+func (f *FlagSet) Float64(
+	val *float64, short rune, long string, base float64, usage string) *Flag {
+	*val = base
+	return f.define((*float64Val)(val), short, long, usage)
+}
+
+// Duration defines a time.Duration flag with the specified short and/or long
+// variants, base value, and usage text. The argument val points to where the
+// value is stored. Values are parsed with time.ParseDuration.
+func (f *FlagSet) Duration(
+	val *time.Duration,
+	short rune,
+	long string,
+	base time.Duration,
+	usage string) *Flag {
+	*val = base
+	return f.define((*durationVal)(val), short, long, usage)
+}
+
+// Count defines a counting flag with the specified short and/or long variants,
+// base value, and usage text. The argument val points to where the value is
+// stored. Like a bool flag a counting flag does not take a value; instead the
+// value is incremented each time the flag is given, so -vvv counts 3.
+func (f *FlagSet) Count(
+	val *int, short rune, long string, base int, usage string) *Flag {
+	*val = base
+	return f.define((*countVal)(val), short, long, usage)
+}
+
+// StringSlice defines a repeatable string flag with the specified short and/or
+// long variants, base value, and usage text. The argument val points to where
+// the value is stored. Each time the flag is given its value is appended, so
+// -I a -I b yields [a b]; the first time replaces the base value.
+func (f *FlagSet) StringSlice(
+	val *[]string, short rune, long string, base []string, usage string) *Flag {
+	return f.StringSliceSep(val, short, long, "", base, usage)
+}
+
+// StringSliceSep is the same as StringSlice except that each value is split
+// around sep before being appended, so with a sep of "," -I a,b -I c yields
+// [a b c]. An empty sep disables splitting.
+func (f *FlagSet) StringSliceSep(
+	val *[]string,
+	short rune,
+	long string,
+	sep string,
+	base []string,
+	usage string) *Flag {
+	*val = base
+	return f.define(&sliceVal{val, sep, false}, short, long, usage)
+}
+
+// StringMap defines a repeatable key=value flag with the specified short
+// and/or long variants, base value, and usage text. The argument val points to
+// where the value is stored. Each time the flag is given its value is split
+// around the first "=" and added to the map, so --label a=1 --label b=2
+// yields map[a:1 b:2]; the first time replaces the base value. The base value
+// is never modified.
+func (f *FlagSet) StringMap(
+	val *map[string]string,
+	short rune,
+	long string,
+	base map[string]string,
+	usage string) *Flag {
+	*val = base
+	return f.define(&mapVal{val, false}, short, long, usage)
+}
+
+// Var defines a flag on CommandLine. See FlagSet.Var.
+func Var(val Value, short rune, long string, usage string) *Flag {
+	return CommandLine.Var(val, short, long, usage)
+}
+
+// Bool defines a bool flag on CommandLine. See FlagSet.Bool.
+func Bool(val *bool, short rune, long string, base bool, usage string) *Flag {
+	return CommandLine.Bool(val, short, long, base, usage)
+}
+
+// Int64 defines an int64 flag on CommandLine. See FlagSet.Int64.
+func Int64(
+	val *int64, short rune, long string, base int64, usage string) *Flag {
+	return CommandLine.Int64(val, short, long, base, usage)
+}
+
+// String defines a string flag on CommandLine. See FlagSet.String.
+func String(
+	val *string, short rune, long string, base string, usage string) *Flag {
+	return CommandLine.String(val, short, long, base, usage)
+}
+
+// Int defines an int flag on CommandLine. See FlagSet.Int.
+func Int(val *int, short rune, long string, base int, usage string) *Flag {
+	return CommandLine.Int(val, short, long, base, usage)
+}
+
+// Uint defines a uint flag on CommandLine. See FlagSet.Uint.
+func Uint(val *uint, short rune, long string, base uint, usage string) *Flag {
+	return CommandLine.Uint(val, short, long, base, usage)
+}
+
+// Uint64 defines a uint64 flag on CommandLine. See FlagSet.Uint64.
+func Uint64(
+	val *uint64, short rune, long string, base uint64, usage string) *Flag {
+	return CommandLine.Uint64(val, short, long, base, usage)
+}
+
+// Float64 defines a float64 flag on CommandLine. See FlagSet.Float64.
+func Float64(
+	val *float64, short rune, long string, base float64, usage string) *Flag {
+	return CommandLine.Float64(val, short, long, base, usage)
+}
+
+// Duration defines a time.Duration flag on CommandLine. See
+// FlagSet.Duration.
+func Duration(
+	val *time.Duration,
+	short rune,
+	long string,
+	base time.Duration,
+	usage string) *Flag {
+	return CommandLine.Duration(val, short, long, base, usage)
+}
+
+// Count defines a counting flag on CommandLine. See FlagSet.Count.
+func Count(val *int, short rune, long string, base int, usage string) *Flag {
+	return CommandLine.Count(val, short, long, base, usage)
+}
+
+// StringSlice defines a repeatable string flag on CommandLine. See
+// FlagSet.StringSlice.
+func StringSlice(
+	val *[]string, short rune, long string, base []string, usage string) *Flag {
+	return CommandLine.StringSlice(val, short, long, base, usage)
+}
+
+// StringSliceSep defines a repeatable string flag on CommandLine. See
+// FlagSet.StringSliceSep.
+func StringSliceSep(
+	val *[]string,
+	short rune,
+	long string,
+	sep string,
+	base []string,
+	usage string) *Flag {
+	return CommandLine.StringSliceSep(val, short, long, sep, base, usage)
+}
+
+// StringMap defines a repeatable key=value flag on CommandLine. See
+// FlagSet.StringMap.
+func StringMap(
+	val *map[string]string,
+	short rune,
+	long string,
+	base map[string]string,
+	usage string) *Flag {
+	return CommandLine.StringMap(val, short, long, base, usage)
+}
+
+// lookupShort finds the short flag r in f or, failing that, in the flag sets
+// of the commands that f is a subcommand of.
+func (f *FlagSet) lookupShort(r rune) (*Flag, bool) {
+	for ; f != nil; f = f.parent {
+		if fl, ok := f.shortFlags[r]; ok {
+			return fl, true
+		}
 	}
-	if long != "" {
-		longFlags[long] = f
+	return nil, false
+}
+
+// lookupLong is the same as lookupShort but for long flags.
+func (f *FlagSet) lookupLong(s string) (*Flag, bool) {
+	for ; f != nil; f = f.parent {
+		if fl, ok := f.longFlags[s]; ok {
+			return fl, true
+		}
+	}
+	return nil, false
+}
+
+// Lookup returns the flag with the long variant name or, if name is a single
+// character, the short variant name, or nil if there is none. Like parsing,
+// it also searches the flag sets of the commands that f is a subcommand of.
+func (f *FlagSet) Lookup(name string) *Flag {
+	var fl *Flag
+	if r, ok := singleRune(name); ok {
+		fl, _ = f.lookupShort(r)
+	} else if name != "" {
+		fl, _ = f.lookupLong(name)
+	}
+	return fl
+}
+
+// Changed reports whether the flag named name was set by anything other than
+// its base value. See FlagSet.Lookup for how flags are named.
+func (f *FlagSet) Changed(name string) bool {
+	fl := f.Lookup(name)
+	return fl != nil && fl.source != SourceDefault
+}
+
+// Visit calls fn for each of the flags defined on f that have been set by
+// anything other than their base values, in the order they were defined.
+func (f *FlagSet) Visit(fn func(*Flag)) {
+	for _, fl := range f.defined {
+		if fl.source != SourceDefault {
+			fn(fl)
+		}
+	}
+}
+
+// VisitAll calls fn for each of the flags defined on f in the order they were
+// defined.
+func (f *FlagSet) VisitAll(fn func(*Flag)) {
+	for _, fl := range f.defined {
+		fn(fl)
+	}
+}
+
+// Lookup looks up a flag on CommandLine. See FlagSet.Lookup.
+func Lookup(name string) *Flag {
+	return CommandLine.Lookup(name)
+}
+
+// Changed reports whether a flag on CommandLine was set. See
+// FlagSet.Changed.
+func Changed(name string) bool {
+	return CommandLine.Changed(name)
+}
+
+// Visit visits the flags on CommandLine that were set. See FlagSet.Visit.
+func Visit(fn func(*Flag)) {
+	CommandLine.Visit(fn)
+}
+
+// VisitAll visits all of the flags on CommandLine. See FlagSet.VisitAll.
+func VisitAll(fn func(*Flag)) {
+	CommandLine.VisitAll(fn)
+}
+
+// Source returns where the current value of fl came from.
+func (fl *Flag) Source() Source {
+	return fl.source
+}
+
+// lookupNegated finds the bool flag that s negates, if s is of the form
+// no-long.
+func (f *FlagSet) lookupNegated(s string) (*Flag, bool) {
+	if !strings.HasPrefix(s, "no-") {
+		return nil, false
+	}
+	fl, ok := f.lookupLong(s[3:])
+	if !ok || !fl.negatable() {
+		return nil, false
 	}
+	return fl, true
+}
+
+// negatable reports whether fl can be given as --no-long.
+func (fl *Flag) negatable() bool {
+	return (fl.Long != "" || len(fl.longs) != 0) && fl.set != nil &&
+		isBoolFlag(fl.Value)
 }
 
 func isFlag(s string) flagType {
@@ -151,126 +944,267 @@ func isFlag(s string) flagType {
 	return notFlag
 }
 
-func parseShortFlag(i int) int {
-	for j, r := range os.Args[i][1:] {
-		f, ok := shortFlags[r]
+// name returns the name of fl as it would be given on the command line,
+// preferring the long variant.
+func (fl *Flag) name() string {
+	var syn Syntax
+	if fl.set != nil {
+		syn = fl.set.getSyntax()
+	}
+	if fl.Long != "" {
+		return syn.longPrefix() + fl.Long
+	}
+	return syn.shortPrefix() + string(fl.Short)
+}
+
+// Implicit makes the value of fl optional and returns fl. When fl is given
+// without an attached value (--color rather than --color=always, or -c rather
+// than -calways) it is set to s instead of taking the next argument, which is
+// never consumed.
+func (fl *Flag) Implicit(s string) *Flag {
+	fl.optional = true
+	fl.implicit = s
+	return fl
+}
+
+// setValue parses s, stores it in the value of the flag fl, and validates it,
+// recording src as where it came from. The variant is the name that fl was
+// given as, without dashes, or "" if it was not given by name.
+func setValue(fl *Flag, s string, src Source, variant string) error {
+	if err := fl.validate(s); err != nil {
+		return invalidValue(s, err)
+	}
+	if fl.source != src && fl.source != SourceDefault {
+		if r, ok := fl.Value.(resetter); ok {
+			r.reset()
+		}
+	}
+	if err := fl.Value.Set(s); err != nil {
+		return invalidValue(s, err)
+	}
+	fl.source = src
+	fl.warnDeprecated(variant)
+	return nil
+}
+
+// parseShortFlag parses the cluster of short flags in args[i] and returns the
+// number of following arguments that were consumed. A flag that takes a value
+// uses the rest of the cluster (-ofile) or, if it is the last in the cluster,
+// the next argument (-xvf archive) unless its value is optional.
+func (f *FlagSet) parseShortFlag(args []string, i int) (int, error) {
+	s := args[i][1:]
+	for j, r := range s {
+		variant := string(r)
+		name := "-" + variant
+		fl, ok := f.lookupShort(r)
 		if !ok {
-			fmt.Fprintf(os.Stderr, "invalid flag: -%c\n", r)
-			PrintUsageAndExit()
-			return 1
+			if err := f.builtinShort(r); err != nil {
+				return 0, err
+			}
+			return 0, &ParseError{i, args[i], name, ErrUnknownFlag}
 		}
-		switch t := f.val.(type) {
-		case *boolVal:
-			t.set(true)
-		case *int64Val:
-			if j != len(os.Args[i])-2 || len(os.Args[i:]) < 2 {
-				fmt.Fprintf(os.Stderr,
-					"-%c must precede integer\n", r)
-				PrintUsageAndExit()
-				return 1
+		if isBoolFlag(fl.Value) {
+			err := setValue(fl, "true", SourceCommandLine, variant)
+			if err != nil {
+				return 0, &ParseError{i, args[i], name, err}
 			}
-			n, err := strconv.ParseInt(os.Args[i+1], 10, 64)
+			continue
+		}
+		if val := s[j+utf8.RuneLen(r):]; val != "" {
+			err := setValue(fl, val, SourceCommandLine, variant)
 			if err != nil {
-				fmt.Fprintf(os.Stderr,
-					"-%c must precede integer\n", r)
-				PrintUsageAndExit()
-				return 1
+				return 0, &ParseError{i, args[i], name, err}
 			}
-			t.set(n)
-			return 1
-		case *stringVal:
-			if j != len(os.Args[i])-2 || len(os.Args[i:]) < 2 {
-				fmt.Fprintf(os.Stderr,
-					"-%c must precede string\n", r)
-				PrintUsageAndExit()
-				return 1
+			return 0, nil
+		}
+		if fl.optional {
+			err := setValue(fl, fl.implicit, SourceCommandLine, variant)
+			if err != nil {
+				return 0, &ParseError{i, args[i], name, err}
 			}
-			t.set(os.Args[i+1])
-			return 1
+			return 0, nil
+		}
+		if i+1 >= len(args) {
+			return 0, &ParseError{i, args[i], name, ErrMissingValue}
 		}
+		err := setValue(fl, args[i+1], SourceCommandLine, variant)
+		if err != nil {
+			return 0, &ParseError{i + 1, args[i+1], name, err}
+		}
+		return 1, nil
 	}
-	return 0
+	return 0, nil
 }
 
-func parseLongFlag(i int) int {
-	f, ok := longFlags[os.Args[i][2:]]
+// parseLongFlag parses the long flag in args[i] and returns the number of
+// following arguments that were consumed. A flag that takes a value uses the
+// text after an equals sign (--long=value) or, failing that, the next
+// argument (--long value) unless its value is optional. A bool flag is set to
+// true unless it is given a value after an equals sign (--long=false) or is
+// negated (--no-long). In syntaxes other than GNUSyntax short flags are
+// parsed here as well.
+func (f *FlagSet) parseLongFlag(args []string, i int) (int, error) {
+	name, key, val, hasVal := f.splitFlag(args[i])
+	if r, ok := singleRune(key); ok && f.getSyntax() != GNUSyntax {
+		fl, ok := f.lookupShort(r)
+		if !ok {
+			if err := f.builtinShort(r); err != nil {
+				return 0, err
+			}
+			return 0, &ParseError{i, args[i], name, ErrUnknownFlag}
+		}
+		return f.setFlag(args, i, name, key, fl, val, hasVal)
+	}
+	long, err := f.expandLong(key)
+	if err != nil {
+		return 0, &ParseError{i, args[i], name, err}
+	}
+	fl, ok := f.lookupLong(long)
 	if !ok {
-		fmt.Fprintf(os.Stderr, "invalid flag: %s\n", os.Args[i])
-		PrintUsageAndExit()
-		return 1
-	}
-	switch t := f.val.(type) {
-	case *boolVal:
-		t.set(true)
-	case *int64Val:
-		if len(os.Args[i:]) < 2 {
-			fmt.Fprintf(os.Stderr,
-				"%s must precede integer\n", os.Args[i])
-			PrintUsageAndExit()
-			return 1
+		if fl, ok = f.lookupNegated(long); ok {
+			if hasVal {
+				return 0, &ParseError{i, args[i], name, ErrUnexpectedValue}
+			}
+			return f.setFlag(args, i, name, long[3:], fl, "false", true)
 		}
-		n, err := strconv.ParseInt(os.Args[i+1], 10, 64)
-		if err != nil {
-			fmt.Fprintf(os.Stderr,
-				"%s must precede integer\n", os.Args[i])
-			PrintUsageAndExit()
-			return 1
+		if err := f.builtinLong(long); err != nil {
+			return 0, err
 		}
-		t.set(n)
-		return 1
-	case *stringVal:
-		if len(os.Args[i:]) < 2 {
-			fmt.Fprintf(os.Stderr, "%s must precede string\n",
-				os.Args[i])
-			PrintUsageAndExit()
-			return 1
+		return 0, &ParseError{i, args[i], name, f.unknownLong(long)}
+	}
+	return f.setFlag(args, i, name, long, fl, val, hasVal)
+}
+
+// setFlag sets fl, which was given as name in args[i] and refers to it by
+// variant, to val if hasVal is true or otherwise to true, its implicit value,
+// or the next argument, depending on its kind, and returns the number of
+// following arguments that were consumed.
+func (f *FlagSet) setFlag(
+	args []string,
+	i int,
+	name, variant string,
+	fl *Flag,
+	val string,
+	hasVal bool) (int, error) {
+	switch {
+	case hasVal:
+	case isBoolFlag(fl.Value):
+		val = "true"
+	case fl.optional:
+		val = fl.implicit
+	case i+1 >= len(args):
+		return 0, &ParseError{i, args[i], name, ErrMissingValue}
+	default:
+		err := setValue(fl, args[i+1], SourceCommandLine, variant)
+		if err != nil {
+			return 0, &ParseError{i + 1, args[i+1], name, err}
 		}
-		t.set(os.Args[i+1])
-		return 1
+		return 1, nil
 	}
-	return 0
+	if err := setValue(fl, val, SourceCommandLine, variant); err != nil {
+		return 0, &ParseError{i, args[i], name, err}
+	}
+	return 0, nil
 }
 
-// Parse parses the command line flags from os.Args[firstFlag:] and returns the
-// index of the first non-flag command line argument. If present, "--" is
-// treated as the end of flags marker and the index of the next argument is
-// returned. If Parse encounters a flag that has not been defined
-// PrintUsageAndExit will be called.
-func Parse(firstFlag int) int {
-	i := firstFlag
-	for ; i <= len(os.Args[firstFlag:]); i++ {
-		switch isFlag(os.Args[i]) {
+// Parse parses flags from args, which should not include the command name,
+// and returns the remaining non-flag arguments. If present, "--" is treated as
+// the end of flags marker and is not included in the returned arguments. By
+// default parsing stops at the first non-flag argument; see SetOrdering.
+//
+// If a flag could not be defined or parsed the error is handled according to
+// the error handling behavior of the flag set. With ContinueOnError the error
+// is returned and is either a *DefineError or a *ParseError. Requests for help
+// or the version are handled as described by SetHelp and Version. Once the
+// flags have been parsed the constraints on them are checked, and if any are
+// violated the error is a *ConstraintError.
+func (f *FlagSet) Parse(args []string) ([]string, error) {
+	args, err := f.expandArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	return f.parseAndCheck(args)
+}
+
+// parseAndCheck parses args, loads the config file, if any, and checks the
+// constraints on the flags of f.
+func (f *FlagSet) parseAndCheck(args []string) ([]string, error) {
+	rest, err := f.parse(args, f.permute())
+	if err != nil {
+		return nil, err
+	}
+	if err = f.loadConfigFlag(); err != nil {
+		return nil, err
+	}
+	if err = f.checkConstraints(); err != nil {
+		return nil, f.fail(err)
+	}
+	return rest, nil
+}
+
+func (f *FlagSet) parse(args []string, permute bool) ([]string, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if ok, err := f.handleComplete(args); ok {
+		return nil, err
+	}
+	var flags, rest []string
+	i := 0
+loop:
+	for ; i < len(args); i++ {
+		var n int
+		var err error
+		switch f.classify(args[i]) {
 		case shortFlag:
-			i += parseShortFlag(i)
+			n, err = f.parseShortFlag(args, i)
 		case longFlag:
-			i += parseLongFlag(i)
+			n, err = f.parseLongFlag(args, i)
 		case endFlag:
-			return i + 1
+			if permute {
+				flags = append(flags, args[i])
+			}
+			i++
+			break loop
 		default:
-			return i
+			if !permute {
+				break loop
+			}
+			rest = append(rest, args[i])
+			continue
 		}
+		if err == ErrHelp || err == ErrVersion {
+			return nil, f.showBuiltin(err)
+		}
+		if err != nil {
+			return nil, f.fail(err)
+		}
+		if permute {
+			flags = append(flags, args[i:i+n+1]...)
+		}
+		i += n
 	}
-	return i
-}
-
-// PrintUsageAndExit prints usage text based on the defined flags and exits.
-func PrintUsageAndExit() {
-	fmt.Fprintf(os.Stderr, "usage of %s:\n", os.Args[0])
-	shortKeys := make([]int, len(shortFlags))
-	for r := range shortFlags {
-		shortKeys = append(shortKeys, int(r))
-	}
-	sort.Ints(shortKeys)
-	for _, r := range shortKeys {
-		shortFlags[rune(r)].printUsageAndDelete()
-	}
-	longKeys := make([]string, len(longFlags))
-	for s := range longFlags {
-		longKeys = append(longKeys, s)
+	if err := f.applyEnv(); err != nil {
+		return nil, f.fail(err)
 	}
-	sort.Strings(longKeys)
-	for _, s := range longKeys {
-		longFlags[s].printUsageAndDelete()
+	if !permute {
+		return args[i:], nil
 	}
-	osExit(1)
+	rest = append(rest, args[i:]...)
+	n := copy(args, flags)
+	copy(args[n:], rest)
+	return args[n:], nil
+}
+
+// Parse parses the command line flags from os.Args[firstFlag:] and returns the
+// index of the first non-flag command line argument. If present, "--" is
+// treated as the end of flags marker and the index of the next argument is
+// returned. If Parse encounters an error the error and usage text are printed
+// and the program exits, unless the error handling behavior of CommandLine
+// has been changed. Response files are not expanded, since the index could
+// not refer to the arguments read from them; CommandLine.Parse and Run expand
+// them.
+func Parse(firstFlag int) int {
+	rest, _ := CommandLine.parseAndCheck(os.Args[firstFlag:])
+	return len(os.Args) - len(rest)
 }
@@ -1,9 +1,14 @@
 package flag
 
 import (
+	"bytes"
+	"errors"
+	"math"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 type flagSet struct {
@@ -20,6 +25,7 @@ type flagSet struct {
 var opt flagSet
 
 func initFlags() {
+	CommandLine = NewFlagSet("test", ExitOnError)
 	Bool(&opt.b, 'b', "bool", false, "bool flag")
 	String(&opt.fStr, 'f', "f64", "", "float64 flag")
 	Int64(&opt.i, 'i', "int", 0, "int flag")
@@ -116,7 +122,7 @@ func TestInvalidFlag(t *testing.T) {
 	osExit = func(code int) {
 		exitCode = code
 	}
-	os.Args = []string{"test", "-h"}
+	os.Args = []string{"test", "-x"}
 	Parse(1)
 	if exitCode != 1 {
 		t.Fail()
@@ -124,7 +130,7 @@ func TestInvalidFlag(t *testing.T) {
 
 	initFlags()
 	exitCode = 0
-	os.Args = []string{"test", "--help"}
+	os.Args = []string{"test", "--xyzzy"}
 	Parse(1)
 	if exitCode != 1 {
 		t.Fail()
@@ -133,6 +139,50 @@ func TestInvalidFlag(t *testing.T) {
 	os.Args = args
 }
 
+func TestHelp(t *testing.T) {
+	initFlags()
+	args := os.Args
+	var out bytes.Buffer
+	CommandLine.SetHelpOutput(&out)
+	exitCode := -1
+	osExit = func(code int) {
+		exitCode = code
+	}
+	for _, arg := range []string{"-bh", "--help"} {
+		os.Args = []string{"test", arg}
+		Parse(1)
+		if exitCode != 0 || !strings.HasPrefix(out.String(), "usage:") {
+			t.Error(arg)
+		}
+		exitCode = -1
+		out.Reset()
+	}
+
+	var v bool
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetHelpOutput(&out)
+	f.Version("test 1.0")
+	c := f.Command("sub", "", nil)
+	if _, err := c.Parse([]string{"--version"}); err != ErrVersion ||
+		out.String() != "test 1.0\n" {
+		t.Fail()
+	}
+	f.SetHelp(false)
+	if _, err := c.Parse([]string{"-h"}); !errors.Is(err, ErrUnknownFlag) {
+		t.Fail()
+	}
+	f.SetHelp(true)
+	c.Bool(&v, 'h', "", false, "")
+	if _, err := c.Parse([]string{"-h"}); err != nil || !v {
+		t.Fail()
+	}
+	if _, err := c.Parse([]string{"--help"}); err != ErrHelp {
+		t.Fail()
+	}
+	osExit = os.Exit
+	os.Args = args
+}
+
 func TestFlagAfterInt64OrStrFlag(t *testing.T) {
 	initFlags()
 	args := os.Args
@@ -150,7 +200,7 @@ func TestFlagAfterInt64OrStrFlag(t *testing.T) {
 	exitCode = 0
 	os.Args = []string{"test", "-fb"}
 	Parse(1)
-	if exitCode != 1 {
+	if exitCode != 0 || opt.fStr != "b" {
 		t.Fail()
 	}
 	osExit = os.Exit
@@ -204,3 +254,372 @@ func TestNothingAfterInt64OrStrFlag(t *testing.T) {
 	osExit = os.Exit
 	os.Args = args
 }
+
+func TestFlagSet(t *testing.T) {
+	var a, b int64
+	fa := NewFlagSet("a", ContinueOnError)
+	fa.Int64(&a, 'n', "num", 0, "")
+	fb := NewFlagSet("b", ContinueOnError)
+	fb.Int64(&b, 'n', "num", 0, "")
+	rest, err := fa.Parse([]string{"-n", "1", "x"})
+	if err != nil || len(rest) != 1 || rest[0] != "x" {
+		t.Fail()
+	}
+	rest, err = fb.Parse([]string{"--num", "2", "--"})
+	if err != nil || len(rest) != 0 {
+		t.Fail()
+	}
+	if a != 1 || b != 2 {
+		t.Fail()
+	}
+}
+
+func TestErrors(t *testing.T) {
+	var b bool
+	var i int64
+	f := NewFlagSet("test", ContinueOnError)
+	f.Bool(&b, 'b', "bool", false, "")
+	f.Int64(&i, 'i', "int", 0, "")
+	var perr *ParseError
+	_, err := f.Parse([]string{"-b", "-x"})
+	if !errors.As(err, &perr) || !errors.Is(err, ErrUnknownFlag) ||
+		perr.Pos != 1 || perr.Name != "-x" {
+		t.Fail()
+	}
+	_, err = f.Parse([]string{"--int"})
+	if !errors.Is(err, ErrMissingValue) {
+		t.Fail()
+	}
+	_, err = f.Parse([]string{"-b", "--int", "1.5"})
+	if !errors.As(err, &perr) || !errors.Is(err, ErrInvalidValue) ||
+		perr.Pos != 2 || perr.Arg != "1.5" || perr.Name != "--int" {
+		t.Fail()
+	}
+
+	f.Bool(&b, 0, "int", false, "")
+	var derr *DefineError
+	_, err = f.Parse(nil)
+	if !errors.As(err, &derr) || !errors.Is(err, ErrDuplicateFlag) ||
+		derr.Name != "--int" {
+		t.Fail()
+	}
+
+	f = NewFlagSet("test", PanicOnError)
+	f.Bool(&b, 'b', "", false, "")
+	defer func() {
+		if !errors.Is(recover().(error), ErrUnknownFlag) {
+			t.Fail()
+		}
+	}()
+	f.Parse([]string{"-a"})
+	t.Fail()
+}
+
+func TestAttachedValues(t *testing.T) {
+	var b bool
+	var i int64
+	var s string
+	f := NewFlagSet("test", ContinueOnError)
+	f.Bool(&b, 'b', "bool", false, "")
+	f.Int64(&i, 'i', "int", 0, "")
+	f.String(&s, 's', "str", "", "")
+	rest, err := f.Parse([]string{"--int=5", "--str=a=b", "x"})
+	if err != nil || i != 5 || s != "a=b" || len(rest) != 1 {
+		t.Fail()
+	}
+	_, err = f.Parse([]string{"-i-7", "-bsfile"})
+	if err != nil || i != -7 || !b || s != "file" {
+		t.Fail()
+	}
+	_, err = f.Parse([]string{"-bs", "archive", "--str="})
+	if err != nil || s != "" {
+		t.Fail()
+	}
+	_, err = f.Parse([]string{"--bool=yes"})
+	if !errors.Is(err, ErrInvalidValue) {
+		t.Fail()
+	}
+	var perr *ParseError
+	_, err = f.Parse([]string{"-bix"})
+	if !errors.As(err, &perr) || !errors.Is(err, ErrInvalidValue) ||
+		perr.Pos != 0 || perr.Name != "-i" {
+		t.Fail()
+	}
+}
+
+func TestPermute(t *testing.T) {
+	var b bool
+	var s string
+	f := NewFlagSet("test", ContinueOnError)
+	f.Bool(&b, 'b', "", false, "")
+	f.String(&s, 's', "", "", "")
+	args := []string{"a", "-b", "b", "-s", "c", "d", "--", "-e"}
+	rest, err := f.Parse(append([]string(nil), args...))
+	if err != nil || b || s != "" || len(rest) != 8 {
+		t.Fail()
+	}
+
+	b = false
+	f.SetOrdering(Permute)
+	os.Unsetenv("POSIXLY_CORRECT")
+	rest, err = f.Parse(args)
+	if err != nil || !b || s != "c" ||
+		strings.Join(rest, " ") != "a b d -e" ||
+		strings.Join(args, " ") != "-b -s c -- a b d -e" {
+		t.Fail()
+	}
+
+	b = false
+	os.Setenv("POSIXLY_CORRECT", "1")
+	rest, err = f.Parse([]string{"a", "-b"})
+	if err != nil || b || len(rest) != 2 {
+		t.Fail()
+	}
+	os.Unsetenv("POSIXLY_CORRECT")
+}
+
+type byteSize int64
+
+func (b *byteSize) Set(s string) error {
+	mul := int64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		mul, s = 1<<10, s[:len(s)-1]
+	case strings.HasSuffix(s, "M"):
+		mul, s = 1<<20, s[:len(s)-1]
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	*b = byteSize(n * mul)
+	return nil
+}
+
+func (b *byteSize) String() string {
+	return strconv.FormatInt(int64(*b), 10)
+}
+
+type toggle int
+
+func (v *toggle) Set(s string) error {
+	*v++
+	return nil
+}
+
+func (v *toggle) String() string {
+	return strconv.Itoa(int(*v))
+}
+
+func (v *toggle) IsBoolFlag() bool {
+	return true
+}
+
+func TestVar(t *testing.T) {
+	var size byteSize
+	var tog toggle
+	f := NewFlagSet("test", ContinueOnError)
+	f.Var(&size, 's', "size", "size in bytes")
+	f.Var(&tog, 't', "toggle", "")
+	_, err := f.Parse([]string{"-tt", "--size=4K", "--toggle"})
+	if err != nil || size != 4096 || tog != 3 {
+		t.Fail()
+	}
+	var perr *ParseError
+	_, err = f.Parse([]string{"-s", "4G"})
+	if !errors.As(err, &perr) || !errors.Is(err, ErrInvalidValue) ||
+		perr.Name != "-s" || perr.Arg != "4G" {
+		t.Fail()
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	var i int
+	var j int64
+	var u uint
+	var n uint64
+	var x float64
+	var d time.Duration
+	f := NewFlagSet("test", ContinueOnError)
+	f.Int(&i, 'i', "int", 0, "")
+	f.Int64(&j, 'j', "int64", 0, "")
+	f.Uint(&u, 'u', "uint", 0, "")
+	f.Uint64(&n, 'n', "uint64", 0, "")
+	f.Float64(&x, 'x', "float64", 0, "")
+	f.Duration(&d, 'd', "duration", time.Second, "")
+	if d != time.Second {
+		t.Fail()
+	}
+	_, err := f.Parse([]string{
+		"-i", "0x1f",
+		"-j", "-1_000_000",
+		"-u", "0b101",
+		"-n", "18446744073709551615",
+		"-x", "1234.5678",
+		"-d", "1m30s",
+	})
+	if err != nil || i != 31 || j != -1000000 || u != 5 ||
+		n != math.MaxUint64 || x != 1234.5678 || d != 90*time.Second {
+		t.Fail()
+	}
+	_, err = f.Parse([]string{"--int64=0o17", "--int=010", "-u", "09"})
+	if err != nil || j != 15 || i != 10 || u != 9 {
+		t.Fail()
+	}
+	for _, args := range [][]string{
+		{"-u", "-1"},
+		{"-n", "18446744073709551616"},
+		{"-j", "9223372036854775808"},
+		{"-x", "1.2.3"},
+		{"-d", "90"},
+		{"-i", "1__0"},
+	} {
+		if _, err = f.Parse(args); !errors.Is(err, ErrInvalidValue) {
+			t.Error(args)
+		}
+	}
+}
+
+func TestRepeatable(t *testing.T) {
+	var v int
+	var inc, tags []string
+	var labels map[string]string
+	base := map[string]string{"a": "0"}
+	f := NewFlagSet("test", ContinueOnError)
+	f.Count(&v, 'v', "verbose", 0, "")
+	f.StringSlice(&inc, 'I', "", []string{"/usr/include"}, "")
+	f.StringSliceSep(&tags, 't', "tags", ",", nil, "")
+	f.StringMap(&labels, 'l', "label", base, "")
+	if len(inc) != 1 || labels["a"] != "0" {
+		t.Fail()
+	}
+	_, err := f.Parse([]string{
+		"-vvv", "-I", "a", "-Ib", "--verbose",
+		"--tags=x,y", "-t", "z",
+		"--label", "k=v", "-lk2=v2=v3",
+	})
+	if err != nil || v != 4 ||
+		strings.Join(inc, " ") != "a b" ||
+		strings.Join(tags, " ") != "x y z" ||
+		len(labels) != 2 || labels["k"] != "v" || labels["k2"] != "v2=v3" ||
+		len(base) != 1 {
+		t.Fail()
+	}
+	for _, args := range [][]string{{"-l", "k"}, {"--label==v"}} {
+		if _, err = f.Parse(args); !errors.Is(err, ErrInvalidValue) {
+			t.Error(args)
+		}
+	}
+}
+
+func TestVisit(t *testing.T) {
+	var i int
+	var s string
+	var b bool
+	os.Setenv("TEST_STR", "env")
+	defer os.Unsetenv("TEST_STR")
+	f := NewFlagSet("test", ContinueOnError)
+	f.Int(&i, 'i', "int", 0, "int flag")
+	f.String(&s, 's', "str", "x", "string flag")
+	f.Bool(&b, 'b', "", false, "")
+	f.SetEnvPrefix("TEST")
+	if _, err := f.Parse([]string{"-i", "0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	fl := f.Lookup("int")
+	if fl == nil || f.Lookup("i") != fl || fl.Short != 'i' ||
+		fl.Usage != "int flag" || fl.Source() != SourceCommandLine ||
+		f.Lookup("bool") != nil || f.Lookup("") != nil {
+		t.Fail()
+	}
+	if fl = f.Lookup("str"); fl.DefValue != "x" ||
+		fl.Value.String() != "env" || fl.Source() != SourceEnv ||
+		fl.Source().String() != "env" {
+		t.Fail()
+	}
+	if !f.Changed("i") || !f.Changed("str") || f.Changed("b") ||
+		f.Changed("nope") {
+		t.Fail()
+	}
+
+	var names []string
+	f.Visit(func(fl *Flag) { names = append(names, fl.name()) })
+	if strings.Join(names, " ") != "--int --str" {
+		t.Error(names)
+	}
+	names = nil
+	f.VisitAll(func(fl *Flag) {
+		names = append(names, fl.name()+"="+fl.Source().String())
+	})
+	if strings.Join(names, " ") !=
+		"--int=command line --str=env -b=default" {
+		t.Error(names)
+	}
+
+	c := f.Command("sub", "", nil)
+	if c.Lookup("int") != f.Lookup("int") || !c.Changed("int") {
+		t.Fail()
+	}
+}
+
+func TestNegatable(t *testing.T) {
+	var b, color bool
+	var v int
+	f := NewFlagSet("test", ContinueOnError)
+	f.Bool(&b, 'b', "bool", false, "")
+	f.Bool(&color, 0, "color", true, "")
+	f.Count(&v, 'v', "verbose", 0, "")
+	_, err := f.Parse([]string{"--no-color", "--bool=1", "-vv"})
+	if err != nil || color || !b || v != 2 {
+		t.Error(err)
+	}
+	_, err = f.Parse([]string{"--color=true", "--bool=false", "--no-verbose"})
+	if err != nil || !color || b || v != 0 {
+		t.Error(err)
+	}
+	_, err = f.Parse([]string{"--bool=0", "--color=f", "--verbose=3"})
+	if err != nil || color || b || v != 3 {
+		t.Error(err)
+	}
+	for _, args := range [][]string{{"--no-color=true"}, {"--no-b"}} {
+		if _, err = f.Parse(args); err == nil {
+			t.Error(args)
+		}
+	}
+
+	var nobody bool
+	f.Bool(&nobody, 0, "no-body", false, "")
+	if _, err = f.Parse([]string{"--no-body"}); err != nil || !nobody {
+		t.Error(err)
+	}
+}
+
+func TestImplicit(t *testing.T) {
+	var color, level string
+	var v bool
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOrdering(Permute)
+	f.String(&color, 'c', "color", "never", "color `WHEN`").Implicit("auto")
+	f.String(&level, 'l', "", "", "").Implicit("info")
+	f.Bool(&v, 'v', "", false, "")
+	rest, err := f.Parse([]string{"--color", "x", "-vl", "y"})
+	if err != nil || color != "auto" || level != "info" ||
+		strings.Join(rest, " ") != "x y" {
+		t.Error(rest, err)
+	}
+	_, err = f.Parse([]string{"--color=always", "-ldebug", "-cv"})
+	if err != nil || color != "v" || level != "debug" {
+		t.Error(err)
+	}
+
+	var b bytes.Buffer
+	f.WriteUsage(&b)
+	if !strings.Contains(b.String(), "-c, --color[=WHEN]  color WHEN") ||
+		!strings.Contains(b.String(), "-l[STRING]") {
+		t.Error(b.String())
+	}
+	if s := completeString(f, "--color", ""); s != ":files\n" {
+		t.Error(s)
+	}
+}
@@ -1 +1,3 @@
 module github.com/iriri/minimal/flag
+
+go 1.20
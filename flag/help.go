@@ -0,0 +1,153 @@
+// Copyright 2018 iriri. All rights reserved. Use of this source code is
+// governed by a BSD-style license which can be found in the LICENSE file.
+
+package flag
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrHelp and ErrVersion are returned by Parse with ContinueOnError when
+// -h/--help or --version is given and has not been defined as a flag.
+var (
+	ErrHelp    = errors.New("help requested")
+	ErrVersion = errors.New("version requested")
+)
+
+// SetHelp enables or disables the built-in -h and --help flags of f and its
+// subcommands. When enabled, which is the default, giving either causes Parse
+// to write the usage text to HelpOutput and then, with ExitOnError, exit with
+// status 0. A flag defined with a short variant of 'h' or a long variant of
+// "help" takes precedence over the built-in one.
+func (f *FlagSet) SetHelp(enabled bool) {
+	f.noHelp = !enabled
+}
+
+// Version sets the version of the program, which enables the built-in
+// --version flag of f and its subcommands. Giving it causes Parse to write
+// version and a newline to HelpOutput and then, with ExitOnError, exit with
+// status 0. A flag defined with a long variant of "version" takes precedence
+// over the built-in one. An empty version disables the flag.
+func (f *FlagSet) Version(version string) {
+	f.version = version
+}
+
+// HelpOutput returns the destination for help and version text and for
+// completions. It is os.Stdout unless changed by SetHelpOutput or, for
+// subcommands, inherited from the parent flag set.
+func (f *FlagSet) HelpOutput() io.Writer {
+	for ; f != nil; f = f.parent {
+		if f.helpOutput != nil {
+			return f.helpOutput
+		}
+	}
+	return os.Stdout
+}
+
+// SetHelpOutput sets the destination for help and version text and for
+// completions.
+func (f *FlagSet) SetHelpOutput(w io.Writer) {
+	f.helpOutput = w
+}
+
+// SetHelp enables or disables the built-in help flags of CommandLine. See
+// FlagSet.SetHelp.
+func SetHelp(enabled bool) {
+	CommandLine.SetHelp(enabled)
+}
+
+// Version sets the version of the program. See FlagSet.Version.
+func Version(version string) {
+	CommandLine.Version(version)
+}
+
+func (f *FlagSet) helpEnabled() bool {
+	for ; f != nil; f = f.parent {
+		if f.noHelp {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *FlagSet) getVersion() string {
+	for ; f != nil; f = f.parent {
+		if f.version != "" {
+			return f.version
+		}
+	}
+	return ""
+}
+
+// builtinShort returns ErrHelp if r is the built-in short help flag.
+func (f *FlagSet) builtinShort(r rune) error {
+	if r == 'h' && f.helpEnabled() {
+		return ErrHelp
+	}
+	return nil
+}
+
+// builtinLong returns ErrHelp or ErrVersion if s is the built-in long help or
+// version flag.
+func (f *FlagSet) builtinLong(s string) error {
+	if s == "help" && f.helpEnabled() {
+		return ErrHelp
+	}
+	if s == "version" && f.getVersion() != "" {
+		return ErrVersion
+	}
+	return nil
+}
+
+// builtinFlags returns stand-ins for the built-in flags of f that have not
+// been shadowed by defined flags so that they can be listed with the others.
+func (f *FlagSet) builtinFlags() []*Flag {
+	var flags []*Flag
+	if f.helpEnabled() {
+		fl := &Flag{Value: new(boolVal), Usage: "show this help and exit"}
+		if _, ok := f.lookupShort('h'); !ok {
+			fl.Short = 'h'
+		}
+		if _, ok := f.lookupLong("help"); !ok {
+			fl.Long = "help"
+		}
+		if fl.Short != 0 || fl.Long != "" {
+			flags = append(flags, fl)
+		}
+	}
+	if _, ok := f.lookupLong("version"); !ok && f.getVersion() != "" {
+		flags = append(flags, &Flag{
+			Value: new(boolVal),
+			Usage: "show the version and exit",
+			Long:  "version",
+		})
+	}
+	return flags
+}
+
+// showBuiltin writes the help or version text requested by err to HelpOutput
+// and then handles err according to the error handling behavior of f.
+func (f *FlagSet) showBuiltin(err error) error {
+	if err == ErrHelp {
+		f.WriteUsage(f.HelpOutput())
+	} else {
+		fmt.Fprintln(f.HelpOutput(), f.getVersion())
+	}
+	return f.exitBuiltin(err)
+}
+
+// exitBuiltin handles the error of a built-in flag or of a completion request
+// according to the error handling behavior of f, exiting with status 0 for
+// ExitOnError.
+func (f *FlagSet) exitBuiltin(err error) error {
+	switch f.errorHandling {
+	case ExitOnError:
+		osExit(0)
+	case PanicOnError:
+		panic(err)
+	}
+	return err
+}
@@ -0,0 +1,280 @@
+// Copyright 2018 iriri. All rights reserved. Use of this source code is
+// governed by a BSD-style license which can be found in the LICENSE file.
+
+package flag
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// WriteMan writes a man page for f and its subcommands to w in the roff
+// format of man(7), for the specified section of the manual. The page is
+// made from the same text as the usage text: the NAME line is the first
+// sentence of the description, the flags are listed under OPTIONS along with
+// a section for each group, and each subcommand has a subsection under
+// COMMANDS. The footer is placed under NOTES. The version, if any, is included
+// in the title line, but no date is, so the output only changes when the flags
+// do.
+func (f *FlagSet) WriteMan(w io.Writer, section int) error {
+	var b strings.Builder
+	name := f.displayName()
+	title := strings.ToUpper(strings.Replace(name, " ", "-", -1))
+	fmt.Fprintf(&b, ".TH %s %d", roffQuote(title), section)
+	if v := f.getVersion(); v != "" {
+		fmt.Fprintf(&b, ` "" %s`, roffQuote(name+" "+v))
+	}
+	b.WriteString("\n.SH NAME\n" + roff(name))
+	if s := f.summary(); s != "" {
+		b.WriteString(` \- ` + roff(s))
+	}
+	b.WriteString("\n.SH SYNOPSIS\n" + f.manUsageLine())
+	if f.description != "" {
+		b.WriteString(".SH DESCRIPTION\n" + roffParagraphs(f.description))
+	}
+	syn := f.getSyntax()
+	for _, sec := range f.usageSections() {
+		if len(sec.rows) == 0 {
+			continue
+		}
+		title := strings.ToUpper(sec.title)
+		if sec.title == "flags" {
+			title = "OPTIONS"
+		}
+		b.WriteString(".SH " + roffQuote(title) + "\n")
+		if sec.title == "commands" {
+			f.writeManCommands(&b)
+			continue
+		}
+		for _, row := range sec.rows {
+			writeManRow(&b, row, syn)
+		}
+	}
+	if f.footer != "" {
+		b.WriteString(".SH NOTES\n" + roffParagraphs(f.footer))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMan writes a man page for CommandLine to w. See FlagSet.WriteMan.
+func WriteMan(w io.Writer, section int) error {
+	return CommandLine.WriteMan(w, section)
+}
+
+// writeManCommands writes a subsection for each of the subcommands of f and,
+// after each, its own subcommands.
+func (f *FlagSet) writeManCommands(b *strings.Builder) {
+	for _, s := range f.commandNames() {
+		c := f.commands[s]
+		b.WriteString(".SS " + roffQuote(c.displayName()) + "\n")
+		b.WriteString(c.manUsageLine())
+		if c.usage != "" {
+			b.WriteString(".PP\n" + roff(c.usage) + "\n")
+		}
+		if c.description != "" {
+			b.WriteString(".PP\n" + roffParagraphs(c.description))
+		}
+		syn := c.getSyntax()
+		for _, sec := range c.usageSections() {
+			if sec.title == "global flags" || sec.title == "commands" {
+				continue
+			}
+			for _, row := range c.docRows(sec) {
+				writeManRow(b, row, syn)
+			}
+		}
+		c.writeManCommands(b)
+	}
+}
+
+func writeManRow(b *strings.Builder, row usageRow, syn Syntax) {
+	b.WriteString(".TP\n")
+	if row.fl == nil {
+		b.WriteString(".B " + roff(row.left) + "\n")
+	} else {
+		ph, _ := unquoteUsage(row.fl)
+		names, sep := flagNames(row.fl, syn)
+		for i, s := range names {
+			if i != 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(`\fB` + roff(s) + `\fR`)
+		}
+		if ph != "" {
+			v := roff(sep) + `\fI` + roff(ph) + `\fR`
+			if row.fl.optional {
+				v = "[" + v + "]"
+			}
+			b.WriteString(v)
+		}
+		b.WriteString("\n")
+	}
+	if row.right != "" {
+		b.WriteString(roff(row.right) + "\n")
+	}
+}
+
+// manUsageLine returns the usage line of f for a man page with the name of
+// the command in bold.
+func (f *FlagSet) manUsageLine() string {
+	line := f.getUsageLine()
+	if !strings.HasPrefix(line, f.name) {
+		return roff(line) + "\n"
+	}
+	s := ".B " + roff(f.displayName()) + "\n"
+	if rest := strings.TrimSpace(line[len(f.name):]); rest != "" {
+		s += roff(rest) + "\n"
+	}
+	return s
+}
+
+// roff escapes s so that it is printed as is in a man page.
+func roff(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
+
+func roffQuote(s string) string {
+	return `"` + strings.Replace(roff(s), `"`, `\(dq`, -1) + `"`
+}
+
+// roffParagraphs returns the paragraphs of s as lines of roff separated by
+// paragraph breaks.
+func roffParagraphs(s string) string {
+	paras := paragraphs(s)
+	for i, p := range paras {
+		paras[i] = roff(p) + "\n"
+	}
+	return strings.Join(paras, ".PP\n")
+}
+
+// WriteMarkdown writes a reference for f and its subcommands to w in
+// Markdown. It has a heading for the name of f followed by the description,
+// the usage line, a table for each section of the usage text, and the
+// footer, and then the same for each subcommand under a smaller heading. The
+// output only changes when the flags do.
+func (f *FlagSet) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	f.writeMarkdown(&b, "#")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMarkdown writes a Markdown reference for CommandLine to w. See
+// FlagSet.WriteMarkdown.
+func WriteMarkdown(w io.Writer) error {
+	return CommandLine.WriteMarkdown(w)
+}
+
+func (f *FlagSet) writeMarkdown(b *strings.Builder, heading string) {
+	b.WriteString(heading + " " + mdEscape(f.displayName()) + "\n\n")
+	if f.usage != "" {
+		b.WriteString(mdEscape(f.usage) + "\n\n")
+	}
+	for _, p := range paragraphs(f.description) {
+		b.WriteString(mdEscape(p) + "\n\n")
+	}
+	line := f.getUsageLine()
+	if strings.HasPrefix(line, f.name) {
+		line = f.displayName() + line[len(f.name):]
+	}
+	b.WriteString(heading + "# Usage\n\n```\n" + line + "\n```\n\n")
+	for _, sec := range f.usageSections() {
+		rows := f.docRows(sec)
+		if len(rows) == 0 || sec.title == "global flags" {
+			continue
+		}
+		title := strings.ToUpper(sec.title[:1]) + sec.title[1:]
+		b.WriteString(heading + "# " + mdEscape(title) + "\n\n")
+		if sec.title == "commands" {
+			b.WriteString("| Command | Description |\n| --- | --- |\n")
+		} else {
+			b.WriteString("| Flag | Description |\n| --- | --- |\n")
+		}
+		for _, row := range rows {
+			left := strings.TrimSpace(row.left)
+			fmt.Fprintf(b, "| `%s` | %s |\n",
+				strings.Replace(left, "|", `\|`, -1), mdEscape(row.right))
+		}
+		b.WriteString("\n")
+	}
+	for _, p := range paragraphs(f.footer) {
+		b.WriteString(mdEscape(p) + "\n\n")
+	}
+	sub := heading
+	if len(sub) < 2 {
+		sub += "#"
+	}
+	for _, s := range f.commandNames() {
+		f.commands[s].writeMarkdown(b, sub)
+	}
+}
+
+// docRows returns the rows of sec to document for f. The built-in flags are
+// only documented for the command that is not a subcommand.
+func (f *FlagSet) docRows(sec usageSection) []usageRow {
+	if f.parent == nil {
+		return sec.rows
+	}
+	var rows []usageRow
+	for _, row := range sec.rows {
+		if row.fl == nil || row.fl.set != nil {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// mdEscape escapes the characters in s that Markdown would otherwise treat
+// as formatting.
+func mdEscape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+		"<", `\<`, ">", `\>`, "|", `\|`).Replace(s)
+}
+
+// paragraphs splits s into paragraphs separated by blank lines and joins the
+// lines of each.
+func paragraphs(s string) []string {
+	var paras []string
+	for _, p := range strings.Split(strings.TrimSpace(s), "\n\n") {
+		if p = strings.Join(strings.Fields(p), " "); p != "" {
+			paras = append(paras, p)
+		}
+	}
+	return paras
+}
+
+// displayName returns the name of f with any directories removed from the
+// name of the program.
+func (f *FlagSet) displayName() string {
+	fields := strings.Fields(f.name)
+	if len(fields) == 0 {
+		return ""
+	}
+	fields[0] = filepath.Base(fields[0])
+	return strings.Join(fields, " ")
+}
+
+// summary returns a short description of f: its one line of usage text if it
+// is a subcommand or otherwise the first sentence of its description.
+func (f *FlagSet) summary() string {
+	if f.usage != "" {
+		return f.usage
+	}
+	paras := paragraphs(f.description)
+	if len(paras) == 0 {
+		return ""
+	}
+	s := paras[0]
+	if i := strings.Index(s, ". "); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSuffix(s, ".")
+}
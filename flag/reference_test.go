@@ -0,0 +1,200 @@
+package flag
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newReferenceFlagSet() *FlagSet {
+	var b bool
+	var i, n int64
+	var s, out, color string
+	var d time.Duration
+	f := NewFlagSet("/usr/bin/tool", ContinueOnError)
+	f.SetDescription("Tool does things. It does so many things that they " +
+		"cannot all be listed on one line.\n\nIt is a tool.")
+	f.SetFooter("Report bugs to nobody.")
+	f.Version("1.2")
+	f.Bool(&b, 'b', "bool", false, "bool flag")
+	f.Int64(&i, 'i', "int", 0, "int flag").Required()
+	f.String(&s, 0, "s-t-r", "x", "string flag").Env("TOOL_STR")
+	f.String(&color, 0, "color", "auto", "color `WHEN`").Implicit("always")
+	f.Group("output")
+	f.String(&out, 'o', "", "", "write to `FILE`")
+	f.Group("")
+	f.Duration(&d, 'd', "", time.Second, "delay")
+	add := f.Command("add", "add things", nil)
+	add.SetDescription("Adds *things*.")
+	add.Int64(&n, 'n', "num", 3, "")
+	rm := f.Command("remove", "remove things", nil)
+	rm.Command("all", "remove all things", nil)
+	return f
+}
+
+func TestWriteMan(t *testing.T) {
+	want := `.TH "TOOL" 1 "" "tool 1.2"
+.SH NAME
+tool \- Tool does things
+.SH SYNOPSIS
+.B tool
+[flags] <command>
+.SH DESCRIPTION
+Tool does things. It does so many things that they cannot all be listed on one line.
+.PP
+It is a tool.
+.SH "OPTIONS"
+.TP
+\fB\-b\fR, \fB\-\-[no\-]bool\fR
+bool flag
+.TP
+\fB\-i\fR, \fB\-\-int\fR=\fIN\fR
+int flag (default 0) (required)
+.TP
+\fB\-\-s\-t\-r\fR=\fISTRING\fR
+string flag (default "x") [$TOOL_STR]
+.TP
+\fB\-\-color\fR[=\fIWHEN\fR]
+color WHEN (default "auto")
+.TP
+\fB\-d\fR \fIDURATION\fR
+delay (default 1s)
+.TP
+\fB\-h\fR, \fB\-\-help\fR
+show this help and exit
+.TP
+\fB\-\-version\fR
+show the version and exit
+.SH "OUTPUT"
+.TP
+\fB\-o\fR \fIFILE\fR
+write to FILE
+.SH "COMMANDS"
+.SS "tool add"
+.B tool add
+[flags]
+.PP
+add things
+.PP
+Adds *things*.
+.TP
+\fB\-n\fR, \fB\-\-num\fR=\fIN\fR
+(default 3)
+.SS "tool remove"
+.B tool remove
+[flags] <command>
+.PP
+remove things
+.SS "tool remove all"
+.B tool remove all
+[flags]
+.PP
+remove all things
+.SH NOTES
+Report bugs to nobody.
+`
+	for n := 0; n < 2; n++ {
+		var b bytes.Buffer
+		err := newReferenceFlagSet().WriteMan(&b, 1)
+		if err != nil || b.String() != want {
+			t.Errorf("%s", b.String())
+		}
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	// Backquotes are written as single quotes so that want can be a raw
+	// string.
+	want := strings.Replace(`# tool
+
+Tool does things. It does so many things that they cannot all be listed on one line.
+
+It is a tool.
+
+## Usage
+
+'''
+tool [flags] <command>
+'''
+
+## Flags
+
+| Flag | Description |
+| --- | --- |
+| '-b, --[no-]bool' | bool flag |
+| '-i, --int=N' | int flag (default 0) (required) |
+| '--s-t-r=STRING' | string flag (default "x") \[$TOOL\_STR\] |
+| '--color[=WHEN]' | color WHEN (default "auto") |
+| '-d DURATION' | delay (default 1s) |
+| '-h, --help' | show this help and exit |
+| '--version' | show the version and exit |
+
+## Output
+
+| Flag | Description |
+| --- | --- |
+| '-o FILE' | write to FILE |
+
+## Commands
+
+| Command | Description |
+| --- | --- |
+| 'add' | add things |
+| 'remove' | remove things |
+
+Report bugs to nobody.
+
+## tool add
+
+add things
+
+Adds \*things\*.
+
+### Usage
+
+'''
+tool add [flags]
+'''
+
+### Flags
+
+| Flag | Description |
+| --- | --- |
+| '-n, --num=N' | (default 3) |
+
+## tool remove
+
+remove things
+
+### Usage
+
+'''
+tool remove [flags] <command>
+'''
+
+### Commands
+
+| Command | Description |
+| --- | --- |
+| 'all' | remove all things |
+
+## tool remove all
+
+remove all things
+
+### Usage
+
+'''
+tool remove all [flags]
+'''
+
+`, "'", "`", -1)
+	for n := 0; n < 2; n++ {
+		var b bytes.Buffer
+		err := newReferenceFlagSet().WriteMarkdown(&b)
+		if err != nil || b.String() != want {
+			t.Errorf("%s", b.String())
+		}
+	}
+}
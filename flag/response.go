@@ -0,0 +1,204 @@
+// Copyright 2018 iriri. All rights reserved. Use of this source code is
+// governed by a BSD-style license which can be found in the LICENSE file.
+
+package flag
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// ErrResponseCycle is wrapped by ResponseFileError when a response file
+// includes itself, directly or indirectly.
+var ErrResponseCycle = errors.New("response file includes itself")
+
+// ResponseFileError records a response file that could not be expanded.
+type ResponseFileError struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (e *ResponseFileError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+func (e *ResponseFileError) Unwrap() error {
+	return e.Err
+}
+
+// SetResponseFiles enables or disables response files. When enabled, Parse
+// and Run replace each argument of the form @file with the arguments read
+// from file before parsing. Arguments in a response file are separated by
+// whitespace and may be quoted with double quotes, in which \" and \\ are
+// escapes, or single quotes, in which there are none. Outside of quotes a
+// backslash escapes the next character and # starts a comment that runs to
+// the end of the line if it is at the start of an argument. Arguments of the
+// form @file in a response file are expanded in turn. Arguments after "--"
+// are never expanded.
+//
+// Response files are expanded by flag sets that are not subcommands, before
+// any subcommand is selected. The package-level Parse never expands them,
+// since it returns an index into os.Args; CommandLine.Parse returns the
+// remaining arguments instead. Response files are disabled by default.
+//
+// If a response file given on the command line cannot be read the error is a
+// *ParseError. Other errors are *ResponseFileErrors that point at the file
+// and line of the problem.
+func (f *FlagSet) SetResponseFiles(enabled bool) {
+	f.responseFiles = enabled
+}
+
+// SetResponseFiles enables or disables response files for CommandLine. See
+// FlagSet.SetResponseFiles.
+func SetResponseFiles(enabled bool) {
+	CommandLine.SetResponseFiles(enabled)
+}
+
+type responseExpander struct {
+	out   []string
+	files []string // the absolute paths of the files being expanded
+	end   bool     // whether "--" has been seen
+}
+
+// expandArgs returns args with response files expanded if they are enabled.
+func (f *FlagSet) expandArgs(args []string) ([]string, error) {
+	if f.parent != nil || !f.responseFiles ||
+		f.completion && len(args) != 0 && args[0] == completeCommand {
+		return args, nil
+	}
+	var e responseExpander
+	for i, arg := range args {
+		if e.end || arg == "--" || len(arg) < 2 || arg[0] != '@' {
+			e.end = e.end || arg == "--"
+			e.out = append(e.out, arg)
+			continue
+		}
+		if err := e.expand(arg[1:]); err != nil {
+			if _, ok := err.(*ResponseFileError); !ok {
+				err = &ParseError{i, arg, arg, err}
+			}
+			return nil, f.fail(err)
+		}
+	}
+	return e.out, nil
+}
+
+// expand appends the arguments read from the response file at path.
+func (e *responseExpander) expand(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	e.files = append(e.files, abs)
+	defer func() { e.files = e.files[:len(e.files)-1] }()
+
+	args, lines, err := splitResponseFile(string(data))
+	if err != nil {
+		return &ResponseFileError{path, lines[0], err}
+	}
+	for i, arg := range args {
+		if e.end || arg == "--" || len(arg) < 2 || arg[0] != '@' {
+			e.end = e.end || arg == "--"
+			e.out = append(e.out, arg)
+			continue
+		}
+		if err = e.include(arg[1:]); err != nil {
+			if rerr, ok := err.(*ResponseFileError); ok {
+				return rerr
+			}
+			return &ResponseFileError{path, lines[i], err}
+		}
+	}
+	return nil
+}
+
+// include expands the response file at path unless it is already being
+// expanded.
+func (e *responseExpander) include(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	for _, s := range e.files {
+		if s == abs {
+			return fmt.Errorf("@%s: %w", path, ErrResponseCycle)
+		}
+	}
+	return e.expand(path)
+}
+
+// splitResponseFile splits s into arguments and returns them along with the
+// lines that they start on. If s is malformed the first line returned is the
+// line of the error.
+func splitResponseFile(s string) ([]string, []int, error) {
+	var args []string
+	var lines []int
+	var b strings.Builder
+	line, start := 1, 0
+	var quote rune
+	inArg, escaped, comment := false, false, false
+	begin := func() {
+		if !inArg {
+			inArg, start = true, line
+		}
+	}
+	for _, r := range s {
+		switch {
+		case comment:
+			comment = r != '\n'
+		case escaped:
+			if quote == '"' && r != '"' && r != '\\' {
+				b.WriteRune('\\')
+			}
+			if r != '\n' || quote != 0 {
+				b.WriteRune(r)
+			}
+			escaped = false
+		case quote != 0:
+			switch {
+			case r == quote:
+				quote = 0
+			case r == '\\' && quote == '"':
+				escaped = true
+			default:
+				b.WriteRune(r)
+			}
+		case r == '\\':
+			begin()
+			escaped = true
+		case r == '"' || r == '\'':
+			begin()
+			quote = r
+		case unicode.IsSpace(r):
+			if inArg {
+				args, lines = append(args, b.String()), append(lines, start)
+				b.Reset()
+				inArg = false
+			}
+		case r == '#' && !inArg:
+			comment = true
+		default:
+			begin()
+			b.WriteRune(r)
+		}
+		if r == '\n' {
+			line++
+		}
+	}
+	if quote != 0 {
+		return nil, []int{start}, errors.New("unterminated quote")
+	}
+	if inArg {
+		args, lines = append(args, b.String()), append(lines, start)
+	}
+	return args, lines, nil
+}
@@ -0,0 +1,123 @@
+package flag
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitResponseFile(t *testing.T) {
+	args, lines, err := splitResponseFile("-o 'a b' # comment\n" +
+		"  \"c:\\dir \\\"x\\\"\" d\\ e\\\n" +
+		"f\n#-v\n'multi\nline' ''")
+	if err != nil || strings.Join(args, "|") !=
+		`-o|a b|c:\dir "x"|d ef|multi`+"\n"+`line|` ||
+		len(lines) != 6 || lines[2] != 2 || lines[3] != 2 ||
+		lines[4] != 5 || lines[5] != 6 {
+		t.Errorf("%q %v %v", args, lines, err)
+	}
+	if _, lines, err = splitResponseFile("a\n\"b\nc"); err == nil ||
+		lines[0] != 2 {
+		t.Error(lines, err)
+	}
+}
+
+func TestResponseFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	inner := write("inner.txt", "-v\n--name 'x y'\n")
+	outer := write("outer.txt", "-i 1\n@"+inner+"\n-- @nope\n")
+
+	var v bool
+	var i int
+	var name string
+	f := NewFlagSet("test", ContinueOnError)
+	f.Bool(&v, 'v', "", false, "")
+	f.Int(&i, 'i', "", 0, "")
+	f.String(&name, 0, "name", "", "")
+	rest, err := f.Parse([]string{"@" + outer, "a"})
+	if err != nil || len(rest) != 2 || rest[0] != "@"+outer {
+		t.Error(rest, err)
+	}
+	f.SetResponseFiles(true)
+	rest, err = f.Parse([]string{"@" + outer, "a"})
+	if err != nil || !v || i != 1 || name != "x y" ||
+		strings.Join(rest, " ") != "@nope a" {
+		t.Error(rest, err)
+	}
+
+	cycle := filepath.Join(dir, "cycle.txt")
+	write("cycle.txt", "-v\n\n@"+cycle+"\n")
+	missing := write("missing.txt", "\n@"+filepath.Join(dir, "nope")+"\n")
+	quote := write("quote.txt", "-v\n'x\n")
+	for _, c := range []struct {
+		arg string
+		err error
+		msg string
+	}{
+		{"@" + cycle, ErrResponseCycle,
+			cycle + ":3: @" + cycle + ": response file includes itself"},
+		{"@" + missing, os.ErrNotExist, missing + ":2: open "},
+		{"@" + quote, nil, quote + ":2: unterminated quote"},
+	} {
+		_, err = f.Parse([]string{c.arg})
+		var rerr *ResponseFileError
+		if !errors.As(err, &rerr) || c.err != nil && !errors.Is(err, c.err) ||
+			!strings.HasPrefix(err.Error(), c.msg) {
+			t.Error(err)
+		}
+	}
+	_, err = f.Parse([]string{"-v", "@" + filepath.Join(dir, "nope")})
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Pos != 1 ||
+		!errors.Is(err, os.ErrNotExist) {
+		t.Error(err)
+	}
+}
+
+func TestResponseFilesParse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "args.txt")
+	if err := os.WriteFile(path, []byte("-b a b c d"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opt = flagSet{}
+	initFlags()
+	SetResponseFiles(true)
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"test", "@" + path}
+	if i := Parse(1); i != 1 || opt.b {
+		t.Error(i, opt.b)
+	}
+	rest, err := CommandLine.Parse(os.Args[1:])
+	if err != nil || !opt.b || len(rest) != 4 {
+		t.Error(rest, err)
+	}
+}
+
+func TestResponseFilesRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "args.txt")
+	if err := os.WriteFile(path, []byte("sub -n 2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	var got []string
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetResponseFiles(true)
+	f.Command("sub", "", func(_ *FlagSet, args []string) error {
+		got = args
+		return nil
+	}).Int(&n, 'n', "", 0, "")
+	if err := f.Run([]string{"@" + path, "x"}); err != nil || n != 2 ||
+		len(got) != 1 || got[0] != "x" {
+		t.Error(err, n, got)
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright 2018 iriri. All rights reserved. Use of this source code is
+// governed by a BSD-style license which can be found in the LICENSE file.
+
+package flag
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrInvalidTag is wrapped by TagError when a struct tag is malformed.
+var ErrInvalidTag = errors.New("invalid tag")
+
+// TagError records a struct field that could not be bound to a flag.
+type TagError struct {
+	Field string // the path to the field, e.g. "Server.Port"
+	Err   error
+}
+
+func (e *TagError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e *TagError) Unwrap() error {
+	return e.Err
+}
+
+// Struct defines a flag for each exported field of the struct that v points
+// to. The flags are configured by the tags of the fields:
+//
+//	flag:"b,bool"     the names of the flag; single characters are short
+//	                  variants and anything else is a long variant, and
+//	                  names after the first of each kind are aliases
+//	flag:"-"          the field is skipped
+//	default:"true"    the base value, parsed the same as on the command line
+//	usage:"be loud"   the usage text
+//	env:"APP_BOOL"    the environment variable, as with Flag.Env
+//	sep:","           the separator for []string fields, as with
+//	                  StringSliceSep
+//
+// Fields without a flag tag are named after the field in lower case with
+// dashes between words, so MaxConns becomes --max-conns. Fields without a
+// default tag keep their current values as their base values. Fields may be
+// of any type supported by the functions that define flags or implement
+// Value through a pointer. Fields that are structs and do not implement Value
+// are bound recursively, with the long variants of their flags prefixed by
+// the name of the field and a dash, so Port in Server becomes --server-port;
+// embedded structs are not prefixed.
+//
+// If a field cannot be bound the error is a *TagError, or a *DefineError if
+// its flag could not be defined, and is handled according to the error
+// handling behavior of the flag set.
+func (f *FlagSet) Struct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return f.failStruct(&TagError{
+			fmt.Sprintf("%T", v), errors.New("not a pointer to a struct")})
+	}
+	prev := f.err
+	if err := f.bindStruct(rv.Elem(), "", ""); err != nil {
+		return f.failStruct(err)
+	}
+	if f.err != prev {
+		// A flag could not be defined and the error was already handled.
+		return f.err
+	}
+	return nil
+}
+
+func (f *FlagSet) failStruct(err error) error {
+	if f.err == nil {
+		f.err = err
+	}
+	return f.fail(err)
+}
+
+// Struct defines flags on CommandLine for the fields of a struct. See
+// FlagSet.Struct.
+func Struct(v interface{}) error {
+	return CommandLine.Struct(v)
+}
+
+var valueType = reflect.TypeOf((*Value)(nil)).Elem()
+
+func (f *FlagSet) bindStruct(v reflect.Value, path, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("flag")
+		field, fv := path+sf.Name, v.Field(i)
+		nested := fv.Kind() == reflect.Struct &&
+			!fv.Addr().Type().Implements(valueType)
+		if sf.PkgPath != "" && !(sf.Anonymous && nested) || tag == "-" {
+			continue
+		}
+		if !nested {
+			if err := f.bindField(fv, sf, field, prefix); err != nil {
+				return err
+			}
+			continue
+		}
+		p := prefix
+		if ok {
+			shorts, longs, err := parseNames(tag)
+			if err != nil || len(shorts) != 0 || len(longs) != 1 {
+				return &TagError{
+					field, fmt.Errorf("%w flag:%q", ErrInvalidTag, tag)}
+			}
+			p += longs[0] + "-"
+		} else if !sf.Anonymous {
+			p += dashed(sf.Name) + "-"
+		}
+		if err := f.bindStruct(fv, field+".", p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *FlagSet) bindField(
+	v reflect.Value, sf reflect.StructField, field, prefix string) error {
+	val, err := fieldValue(v, sf.Tag.Get("sep"))
+	if err != nil {
+		return &TagError{field, err}
+	}
+	tag, ok := sf.Tag.Lookup("flag")
+	if !ok || tag == "" {
+		tag = dashed(sf.Name)
+	}
+	shorts, longs, err := parseNames(tag)
+	if err != nil {
+		return &TagError{field, err}
+	}
+	if def, ok := sf.Tag.Lookup("default"); ok {
+		if err = val.Set(def); err != nil {
+			if nerr, ok := err.(*strconv.NumError); ok {
+				err = nerr.Err
+			}
+			return &TagError{field, fmt.Errorf(
+				"%w default:%q: %v", ErrInvalidTag, def, err)}
+		}
+		if r, ok := val.(resetter); ok {
+			r.reset()
+		}
+	}
+
+	var short rune
+	var long string
+	var aliases []string
+	if len(shorts) != 0 {
+		short = shorts[0]
+		for _, r := range shorts[1:] {
+			aliases = append(aliases, string(r))
+		}
+	}
+	if len(longs) != 0 {
+		long = prefix + longs[0]
+		for _, s := range longs[1:] {
+			aliases = append(aliases, prefix+s)
+		}
+	}
+	fl := f.Var(val, short, long, sf.Tag.Get("usage")).Alias(aliases...)
+	if env, ok := sf.Tag.Lookup("env"); ok {
+		fl.Env(env)
+	}
+	return nil
+}
+
+// fieldValue returns the Value that stores into the field v.
+func fieldValue(v reflect.Value, sep string) (Value, error) {
+	switch p := v.Addr().Interface().(type) {
+	case Value:
+		return p, nil
+	case *bool:
+		return (*boolVal)(p), nil
+	case *int64:
+		return (*int64Val)(p), nil
+	case *string:
+		return (*stringVal)(p), nil
+	case *int:
+		return (*intVal)(p), nil
+	case *uint:
+		return (*uintVal)(p), nil
+	case *uint64:
+		return (*uint64Val)(p), nil
+	case *float64:
+		return (*float64Val)(p), nil
+	case *time.Duration:
+		return (*durationVal)(p), nil
+	case *[]string:
+		return &sliceVal{p, sep, false}, nil
+	case *map[string]string:
+		return &mapVal{p, false}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// parseNames splits the names in a flag tag into short and long variants.
+func parseNames(tag string) ([]rune, []string, error) {
+	var shorts []rune
+	var longs []string
+	for _, s := range strings.Split(tag, ",") {
+		if s == "" || s[0] == '-' || strings.ContainsAny(s, "= \t") {
+			return nil, nil, fmt.Errorf("%w flag:%q", ErrInvalidTag, tag)
+		}
+		if r, n := utf8.DecodeRuneInString(s); n == len(s) {
+			shorts = append(shorts, r)
+		} else {
+			longs = append(longs, s)
+		}
+	}
+	return shorts, longs, nil
+}
+
+// dashed returns the name of a field in lower case with dashes between words,
+// e.g. "max-conns" for MaxConns and "http-port" for HTTPPort.
+func dashed(s string) string {
+	rs := []rune(s)
+	var b strings.Builder
+	for i, r := range rs {
+		if unicode.IsUpper(r) {
+			if i > 0 && (!unicode.IsUpper(rs[i-1]) ||
+				i+1 < len(rs) && unicode.IsLower(rs[i+1])) {
+				b.WriteByte('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
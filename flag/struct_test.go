@@ -0,0 +1,106 @@
+package flag
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+type serverOpts struct {
+	Host string `usage:"host to listen on"`
+	Port int    `flag:"p,port" default:"8080"`
+}
+
+type logOpts struct {
+	Level string `default:"info"`
+}
+
+type structOpts struct {
+	B        bool     `flag:"b,bool" default:"false" usage:"bool flag" env:"APP_BOOL"`
+	F        float64  `flag:"f,f64" usage:"float64 flag"`
+	Tags     []string `flag:"t,tag,tags" sep:","`
+	Labels   map[string]string
+	MaxConns int64 `default:"0x10"`
+	Timeout  time.Duration
+	Server   serverOpts
+	Log      logOpts `flag:"logging"`
+	logOpts
+	Skipped  int `flag:"-"`
+	internal int
+}
+
+func TestStruct(t *testing.T) {
+	os.Setenv("APP_BOOL", "true")
+	defer os.Unsetenv("APP_BOOL")
+	var opts structOpts
+	opts.Timeout = time.Second
+	f := NewFlagSet("test", ContinueOnError)
+	if err := f.Struct(&opts); err != nil {
+		t.Fatal(err)
+	}
+	if opts.MaxConns != 16 || opts.Server.Port != 8080 ||
+		opts.Log.Level != "info" || opts.logOpts.Level != "info" ||
+		f.Lookup("timeout").DefValue != "1s" ||
+		f.Lookup("bool").Usage != "bool flag" ||
+		f.Lookup("skipped") != nil || f.Lookup("internal") != nil {
+		t.Fail()
+	}
+	_, err := f.Parse([]string{
+		"-f", "1.5", "--tags=a,b", "--tag", "c", "--labels", "k=v",
+		"--max-conns", "3", "--timeout=2m", "--server-host", "localhost",
+		"-p", "80", "--logging-level", "debug", "--level", "warn",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.B || opts.F != 1.5 || strings.Join(opts.Tags, " ") != "a b c" ||
+		opts.Labels["k"] != "v" || opts.MaxConns != 3 ||
+		opts.Timeout != 2*time.Minute || opts.Server.Host != "localhost" ||
+		opts.Server.Port != 80 || opts.Log.Level != "debug" ||
+		opts.logOpts.Level != "warn" {
+		t.Errorf("%+v", opts)
+	}
+}
+
+func TestStructErrors(t *testing.T) {
+	for _, c := range []struct {
+		v   interface{}
+		err string
+	}{
+		{&struct {
+			A int `flag:"a,,all"`
+		}{}, `A: invalid tag flag:"a,,all"`},
+		{&struct {
+			A int `flag:"--all"`
+		}{}, `A: invalid tag flag:"--all"`},
+		{&struct {
+			A int `default:"x"`
+		}{}, `A: invalid tag default:"x": invalid syntax`},
+		{&struct {
+			S struct{ A int } `flag:"s,sub"`
+		}{}, `S: invalid tag flag:"s,sub"`},
+		{&struct{ C chan int }{}, "C: unsupported type chan int"},
+		{struct{}{}, "struct {}: not a pointer to a struct"},
+	} {
+		f := NewFlagSet("test", ContinueOnError)
+		err := f.Struct(c.v)
+		var terr *TagError
+		if !errors.As(err, &terr) || err.Error() != c.err {
+			t.Error(err)
+		}
+		if _, perr := f.Parse(nil); perr != err {
+			t.Error(perr)
+		}
+	}
+
+	f := NewFlagSet("test", ContinueOnError)
+	err := f.Struct(&struct {
+		A int `flag:"all"`
+		B int `flag:"all"`
+	}{})
+	if !errors.Is(err, ErrDuplicateFlag) {
+		t.Error(err)
+	}
+}
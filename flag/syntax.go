@@ -0,0 +1,171 @@
+// Copyright 2018 iriri. All rights reserved. Use of this source code is
+// governed by a BSD-style license which can be found in the LICENSE file.
+
+package flag
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Syntax defines how flags are written on the command line. Flags are
+// defined the same regardless of the syntax.
+type Syntax int
+
+const (
+	// GNUSyntax is the default. Short flags are written with one dash and
+	// may be chained (-xvf), and long flags are written with two dashes and
+	// take values after an equals sign or as the next argument (--out=file,
+	// --out file).
+	GNUSyntax Syntax = iota
+	// GoSyntax is the syntax of the standard library's flag package and
+	// X11. Every flag is written with one or two dashes and takes values the
+	// same as long flags do in GNUSyntax (-out=file, -v). Flags are looked
+	// up by their short variants if the name is a single character and by
+	// their long variants otherwise, and short flags cannot be chained.
+	GoSyntax
+	// SlashSyntax is the syntax of many Windows tools. Every flag is written
+	// with a slash and takes values after a colon or equals sign or as the
+	// next argument (/out:file, /v). Flags are looked up the same as in
+	// GoSyntax. Arguments that start with a dash are not flags, except for
+	// "--", which still ends the flags.
+	SlashSyntax
+)
+
+// SetSyntax sets the syntax of flags on the command line for f and, unless
+// they set their own, its subcommands. Usage text, error messages, and shell
+// completion name flags in the syntax.
+func (f *FlagSet) SetSyntax(s Syntax) {
+	f.syntax = s
+	f.syntaxSet = true
+}
+
+// SetSyntax sets the syntax of flags for CommandLine. See FlagSet.SetSyntax.
+func SetSyntax(s Syntax) {
+	CommandLine.SetSyntax(s)
+}
+
+func (f *FlagSet) getSyntax() Syntax {
+	for ; f != nil; f = f.parent {
+		if f.syntaxSet {
+			return f.syntax
+		}
+	}
+	return GNUSyntax
+}
+
+// shortPrefix returns what comes before a short variant in s.
+func (s Syntax) shortPrefix() string {
+	if s == SlashSyntax {
+		return "/"
+	}
+	return "-"
+}
+
+// longPrefix returns what comes before a long variant in s.
+func (s Syntax) longPrefix() string {
+	switch s {
+	case GoSyntax:
+		return "-"
+	case SlashSyntax:
+		return "/"
+	}
+	return "--"
+}
+
+// valueSep returns what separates a long variant from its value in s.
+func (s Syntax) valueSep() string {
+	if s == SlashSyntax {
+		return ":"
+	}
+	return "="
+}
+
+// SetNegativeArgs declares whether f accepts negative numbers as arguments.
+// An argument such as -5 or -1.5 is read as a negative number rather than as
+// short flags unless the digit after the dash is a short variant; when
+// enabled, it is read as a negative number regardless. A flag that takes a
+// value may always be given a negative number as the next argument.
+func (f *FlagSet) SetNegativeArgs(enabled bool) {
+	f.negativeArgs = enabled
+}
+
+// SetNegativeArgs declares whether CommandLine accepts negative numbers as
+// arguments. See FlagSet.SetNegativeArgs.
+func SetNegativeArgs(enabled bool) {
+	CommandLine.SetNegativeArgs(enabled)
+}
+
+// classify returns the kind of the argument s in the syntax of f. In every
+// syntax but GNUSyntax all flags are classified as long flags.
+func (f *FlagSet) classify(s string) flagType {
+	syn := f.getSyntax()
+	if syn == SlashSyntax {
+		switch {
+		case s == "--":
+			return endFlag
+		case len(s) >= 2 && s[0] == '/':
+			return longFlag
+		}
+		return notFlag
+	}
+	t := isFlag(s)
+	switch {
+	case t == shortFlag && f.negativeNumber(s):
+		return notFlag
+	case t == shortFlag && syn == GoSyntax:
+		return longFlag
+	}
+	return t
+}
+
+// negativeNumber reports whether the short flag s is to be read as a negative
+// number instead.
+func (f *FlagSet) negativeNumber(s string) bool {
+	if !isNumber(s[1:]) {
+		return false
+	}
+	if f.negativeArgs {
+		return true
+	}
+	_, ok := f.lookupShort(rune(s[1]))
+	return !ok
+}
+
+// isNumber reports whether s is an unsigned number in any of the forms that
+// the strconv package accepts, such as 5, 1.5, 1e3, or 0x1f.
+func isNumber(s string) bool {
+	if s == "" || (s[0] < '0' || s[0] > '9') && s[0] != '.' {
+		return false
+	}
+	if _, err := strconv.ParseUint(s, 0, 64); err == nil {
+		return true
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// splitFlag splits the flag s into the flag as written, without its value,
+// its name, and its value, if it has one.
+func (f *FlagSet) splitFlag(s string) (string, string, string, bool) {
+	n, seps := 2, "="
+	switch f.getSyntax() {
+	case GoSyntax:
+		if !strings.HasPrefix(s, "--") {
+			n = 1
+		}
+	case SlashSyntax:
+		n, seps = 1, ":="
+	}
+	if i := strings.IndexAny(s, seps); i >= 0 {
+		return s[:i], s[n:i], s[i+1:], true
+	}
+	return s, s[n:], "", false
+}
+
+// singleRune returns the rune that s consists of, if it is a single rune.
+func singleRune(s string) (rune, bool) {
+	r, n := utf8.DecodeRuneInString(s)
+	return r, n != 0 && n == len(s)
+}
@@ -0,0 +1,170 @@
+package flag
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type syntaxOpts struct {
+	v     bool
+	color bool
+	n     int
+	out   string
+	level string
+}
+
+func newSyntaxFlagSet(opt *syntaxOpts, syn Syntax) *FlagSet {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetSyntax(syn)
+	f.Bool(&opt.v, 'v', "verbose", false, "")
+	f.Bool(&opt.color, 0, "color", true, "")
+	f.Int(&opt.n, 'n', "", 0, "")
+	f.String(&opt.out, 'o', "out", "", "write to `FILE`").Required()
+	f.String(&opt.level, 0, "log", "", "").Implicit("info")
+	return f
+}
+
+func TestSyntax(t *testing.T) {
+	for _, c := range []struct {
+		syn  Syntax
+		args []string
+	}{
+		{GNUSyntax, []string{
+			"-vn3", "--out", "a", "--no-color", "--log", "x", "--", "-y"}},
+		{GoSyntax, []string{
+			"-v", "-n=3", "-out", "a", "--no-color", "-log", "x", "--", "-y"}},
+		{SlashSyntax, []string{
+			"/v", "/n:3", "/out", "a", "/no-color", "/log", "x", "--", "-y"}},
+	} {
+		var opt syntaxOpts
+		f := newSyntaxFlagSet(&opt, c.syn)
+		rest, err := f.Parse(c.args)
+		if err != nil || !opt.v || opt.n != 3 || opt.out != "a" ||
+			opt.color || opt.level != "info" ||
+			strings.Join(rest, " ") != "x -- -y" {
+			t.Error(c.syn, rest, err)
+		}
+	}
+
+	var opt syntaxOpts
+	f := newSyntaxFlagSet(&opt, SlashSyntax)
+	rest, err := f.Parse([]string{"/o=a", "/log:debug", "-5", "/verbose"})
+	if err != nil || opt.out != "a" || opt.level != "debug" ||
+		len(rest) != 2 || rest[0] != "-5" {
+		t.Error(rest, err)
+	}
+	_, err = f.Parse([]string{"/x"})
+	if !errors.Is(err, ErrUnknownFlag) || err.Error() != "/x: unknown flag" {
+		t.Error(err)
+	}
+	_, err = newSyntaxFlagSet(&opt, GoSyntax).Parse(nil)
+	if err == nil || err.Error() != "-out: flag is required" {
+		t.Error(err)
+	}
+	f = newSyntaxFlagSet(&opt, GoSyntax)
+	if _, err = f.Parse([]string{"-vn", "3"}); !errors.Is(err, ErrUnknownFlag) {
+		t.Error(err)
+	}
+	_, err = f.Parse([]string{"-outt=a"})
+	if !errors.Is(err, ErrUnknownFlag) ||
+		!strings.HasSuffix(err.Error(), "(did you mean -out?)") {
+		t.Error(err)
+	}
+}
+
+func TestSyntaxUsage(t *testing.T) {
+	var opt syntaxOpts
+	f := newSyntaxFlagSet(&opt, SlashSyntax)
+	f.Command("sub", "", nil)
+	want := `usage: test [flags] <command>
+
+flags:
+  /v, /[no-]verbose
+      /[no-]color    (default true)
+  /n:N               (default 0)
+  /o, /out:FILE      write to FILE (required)
+      /log[:STRING]
+  /h, /help          show this help and exit
+
+commands:
+  sub
+`
+	var b bytes.Buffer
+	if f.WriteUsage(&b); b.String() != want {
+		t.Error(b.String())
+	}
+
+	b.Reset()
+	f.commands["sub"].WriteUsage(&b)
+	if !strings.Contains(b.String(), "  /o, /out:FILE") {
+		t.Error(b.String())
+	}
+}
+
+func TestNegativeArgs(t *testing.T) {
+	var v, five bool
+	var n int
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOrdering(Permute)
+	f.Bool(&v, 'v', "", false, "")
+	f.Int(&n, 'n', "", 0, "")
+	rest, err := f.Parse([]string{"-5", "-v", "-n", "-3", "-1.5", "-.5e3"})
+	if err != nil || !v || n != -3 ||
+		strings.Join(rest, " ") != "-5 -1.5 -.5e3" {
+		t.Error(rest, err)
+	}
+	for _, s := range []string{"-x5", "-1x", "-e3", "-."} {
+		if _, err = f.Parse([]string{s}); !errors.Is(err, ErrUnknownFlag) {
+			t.Error(s, err)
+		}
+	}
+
+	f.Bool(&five, '5', "", false, "")
+	rest, err = f.Parse([]string{"-5", "-0x10"})
+	if err != nil || !five || len(rest) != 1 || rest[0] != "-0x10" {
+		t.Error(rest, err)
+	}
+	five = false
+	f.SetNegativeArgs(true)
+	rest, err = f.Parse([]string{"-5", "-vn", "-2"})
+	if err != nil || five || n != -2 || len(rest) != 1 || rest[0] != "-5" {
+		t.Error(rest, err)
+	}
+
+	f = NewFlagSet("test", ContinueOnError)
+	f.SetSyntax(GoSyntax)
+	f.Int(&n, 'n', "", 0, "")
+	rest, err = f.Parse([]string{"-n", "-7", "-42"})
+	if err != nil || n != -7 || len(rest) != 1 || rest[0] != "-42" {
+		t.Error(rest, err)
+	}
+}
+
+func TestSyntaxComplete(t *testing.T) {
+	var opt syntaxOpts
+	for _, c := range []struct {
+		syn   Syntax
+		words []string
+		out   string
+	}{
+		{GoSyntax, []string{"-ou"}, "-out\twrite to FILE\n:\n"},
+		{GoSyntax, []string{"-no"}, "-no-verbose\t\n-no-color\t\n:\n"},
+		{GoSyntax, []string{"-n", ""}, ":files\n"},
+		{GoSyntax, []string{"-v", "-o", ""}, ":files\n"},
+		{GoSyntax, []string{"--ou"}, ":\n"},
+		{SlashSyntax, []string{"/"}, "/verbose\t\n/v\t\n/color\t\n" +
+			"/n\t\n/out\twrite to FILE\n/o\twrite to FILE\n/log\t\n" +
+			"/help\tshow this help and exit\n" +
+			"/h\tshow this help and exit\n:\n"},
+		{SlashSyntax, []string{"/out", ""}, ":files\n"},
+		{SlashSyntax, []string{"/v", "/log:"}, ":files\n"},
+		{SlashSyntax, []string{"-"}, ":files\n"},
+	} {
+		f := newSyntaxFlagSet(&opt, c.syn)
+		if out := completeString(f, c.words...); out != c.out {
+			t.Errorf("%v %q: %q", c.syn, c.words, out)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+// Copyright 2018 iriri. All rights reserved. Use of this source code is
+// governed by a BSD-style license which can be found in the LICENSE file.
+
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+
+package flag
+
+import "os"
+
+// terminalWidth returns 0 since the size of terminals cannot be queried on
+// this platform.
+func terminalWidth(file *os.File) int {
+	return 0
+}
@@ -0,0 +1,31 @@
+// Copyright 2018 iriri. All rights reserved. Use of this source code is
+// governed by a BSD-style license which can be found in the LICENSE file.
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package flag
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// terminalWidth returns the number of columns of the terminal that file
+// refers to, or 0 if it is not a terminal.
+func terminalWidth(file *os.File) int {
+	conn, err := file.SyscallConn()
+	if err != nil {
+		return 0
+	}
+	var ws struct{ row, col, xpixel, ypixel uint16 }
+	var errno syscall.Errno
+	err = conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd,
+			uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	})
+	if err != nil || errno != 0 {
+		return 0
+	}
+	return int(ws.col)
+}
@@ -0,0 +1,392 @@
+// Copyright 2018 iriri. All rights reserved. Use of this source code is
+// governed by a BSD-style license which can be found in the LICENSE file.
+
+package flag
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	defaultWidth = 80
+	minWidth     = 40
+	maxLeftWidth = 32
+)
+
+// SetUsageLine sets the text printed after "usage: " at the top of the usage
+// text. By default it is the name of the flag set followed by [flags] if any
+// flags are defined and <command> if any subcommands are.
+func (f *FlagSet) SetUsageLine(s string) {
+	f.usageLine = s
+}
+
+// SetDescription sets the text printed between the usage line and the flags.
+// It is wrapped to the width of the usage text; blank lines separate
+// paragraphs.
+func (f *FlagSet) SetDescription(s string) {
+	f.description = s
+}
+
+// SetFooter sets the text printed at the end of the usage text. It is wrapped
+// the same as the description.
+func (f *FlagSet) SetFooter(s string) {
+	f.footer = s
+}
+
+// Group causes the flags defined on f after it is called to be listed under
+// title in the usage text rather than with the other flags. An empty title
+// ends the group.
+func (f *FlagSet) Group(title string) {
+	f.group = title
+}
+
+// SetWidth sets the width that usage text is wrapped to. By default it is the
+// value of the COLUMNS environment variable or, failing that, the width of the
+// terminal that the usage text is written to, or 80 if it is not written to a
+// terminal.
+func (f *FlagSet) SetWidth(width int) {
+	f.width = width
+}
+
+// SetUsageLine sets the usage line of CommandLine. See FlagSet.SetUsageLine.
+func SetUsageLine(s string) {
+	CommandLine.SetUsageLine(s)
+}
+
+// SetDescription sets the description of CommandLine. See
+// FlagSet.SetDescription.
+func SetDescription(s string) {
+	CommandLine.SetDescription(s)
+}
+
+// SetFooter sets the footer of CommandLine. See FlagSet.SetFooter.
+func SetFooter(s string) {
+	CommandLine.SetFooter(s)
+}
+
+// Group starts a group of flags on CommandLine. See FlagSet.Group.
+func Group(title string) {
+	CommandLine.Group(title)
+}
+
+func (f *FlagSet) getUsageLine() string {
+	if f.usageLine != "" {
+		return f.usageLine
+	}
+	line := f.name
+	if len(f.defined) != 0 || f.parent != nil {
+		line += " [flags]"
+	}
+	if len(f.commands) != 0 {
+		line += " <command>"
+	}
+	return line
+}
+
+// getWidth returns the width to wrap the usage text written to out to.
+func (f *FlagSet) getWidth(out io.Writer) int {
+	w := f.width
+	if w == 0 {
+		w, _ = strconv.Atoi(os.Getenv("COLUMNS"))
+	}
+	if file, ok := out.(*os.File); ok && w <= 0 {
+		w = terminalWidth(file)
+	}
+	if w <= 0 {
+		w = defaultWidth
+	}
+	if w < minWidth {
+		w = minWidth
+	}
+	return w
+}
+
+// unquoteUsage returns the placeholder for the value of fl and its usage text.
+// The placeholder is taken from the first back-quoted word in the usage text,
+// e.g. "write to `FILE`", if there is one, and is otherwise derived from the
+// type of the value. Flags that do not take a value have no placeholder.
+func unquoteUsage(fl *Flag) (string, string) {
+	name, usage := "", fl.Usage
+	if i := strings.IndexByte(usage, '`'); i >= 0 {
+		if j := strings.IndexByte(usage[i+1:], '`'); j >= 0 {
+			name = usage[i+1 : i+1+j]
+			usage = usage[:i] + name + usage[i+j+2:]
+		}
+	}
+	if isBoolFlag(fl.Value) {
+		return "", usage
+	}
+	if name != "" {
+		return name, usage
+	}
+	switch fl.Value.(type) {
+	case *int64Val, *intVal, *uintVal, *uint64Val:
+		name = "N"
+	case *float64Val:
+		name = "FLOAT"
+	case *durationVal:
+		name = "DURATION"
+	case *stringVal, *sliceVal, *choiceVal:
+		name = "STRING"
+	case *mapVal:
+		name = "KEY=VALUE"
+	default:
+		name = "VALUE"
+	}
+	return name, usage
+}
+
+// flagNames returns the names of fl in syn, starting with its short
+// variants, and what separates them from the placeholder for its value.
+func flagNames(fl *Flag, syn Syntax) ([]string, string) {
+	var names []string
+	if fl.Short != 0 {
+		names = append(names, syn.shortPrefix()+string(fl.Short))
+	}
+	for _, r := range fl.shorts {
+		if fl.aliasListed(string(r)) {
+			names = append(names, syn.shortPrefix()+string(r))
+		}
+	}
+	nshort := len(names)
+	no := ""
+	if fl.negatable() {
+		no = "[no-]"
+	}
+	if fl.Long != "" {
+		names = append(names, syn.longPrefix()+no+fl.Long)
+	}
+	for _, l := range fl.longs {
+		if fl.aliasListed(l) {
+			names = append(names, syn.longPrefix()+no+l)
+		}
+	}
+	sep := syn.valueSep()
+	if syn == GNUSyntax && nshort == len(names) {
+		sep = " "
+		if fl.optional {
+			sep = ""
+		}
+	}
+	return names, sep
+}
+
+// flagSynopsis returns the left column of the usage text for fl, e.g.
+// "-i, --int=N", "-v, --[no-]verbose", or "--color[=WHEN]" in syn. Aliases
+// follow the names that fl was defined with.
+func flagSynopsis(fl *Flag, syn Syntax) string {
+	name, _ := unquoteUsage(fl)
+	names, sep := flagNames(fl, syn)
+	s := strings.Join(names, ", ")
+	if fl.Short == 0 && len(fl.shorts) == 0 {
+		s = "    " + s
+	}
+	switch {
+	case name == "":
+		return s
+	case fl.optional:
+		return s + "[" + sep + name + "]"
+	}
+	return s + sep + name
+}
+
+// flagDescription returns the right column of the usage text for fl: its
+// usage text followed by its allowed values, base value, constraints, and
+// environment variable, if any.
+func flagDescription(fl *Flag) string {
+	_, usage := unquoteUsage(fl)
+	if c, ok := fl.Value.(*choiceVal); ok {
+		usage += " (one of " + strings.Join(c.choices, ", ") + ")"
+	}
+	def := fl.DefValue
+	if _, ok := fl.Value.(*stringVal); ok && def != "" {
+		def = strconv.Quote(def)
+	}
+	if def != "" && !(isBoolFlag(fl.Value) && def == "false") {
+		usage += " (default " + def + ")"
+	}
+	if fl.set != nil {
+		if c := fl.set.constraintUsage(fl); c != "" {
+			usage += " " + c
+		}
+	}
+	if env := fl.envName(); env != "" {
+		usage += " [$" + env + "]"
+	}
+	return strings.TrimSpace(usage)
+}
+
+type usageSection struct {
+	title string
+	rows  []usageRow
+}
+
+type usageRow struct {
+	fl          *Flag // nil for subcommands
+	left, right string
+}
+
+// usageSections returns the sections of flags and subcommands in the usage
+// text of f.
+func (f *FlagSet) usageSections() []usageSection {
+	var secs []usageSection
+	index := make(map[string]int)
+	syn := f.getSyntax()
+	add := func(title string, fl *Flag) {
+		i, ok := index[title]
+		if !ok {
+			i = len(secs)
+			index[title] = i
+			secs = append(secs, usageSection{title: title})
+		}
+		secs[i].rows = append(secs[i].rows,
+			usageRow{fl, flagSynopsis(fl, syn), flagDescription(fl)})
+	}
+	index["flags"] = 0
+	secs = append(secs, usageSection{title: "flags"})
+	for _, fl := range f.defined {
+		if !fl.listed() {
+			continue
+		}
+		title := fl.group
+		if title == "" {
+			title = "flags"
+		}
+		add(title, fl)
+	}
+	for _, fl := range f.builtinFlags() {
+		add("flags", fl)
+	}
+	for c := f.parent; c != nil; c = c.parent {
+		for _, fl := range c.defined {
+			if fl.listed() && f.inherits(fl) {
+				add("global flags", fl)
+			}
+		}
+	}
+	if len(f.commands) != 0 {
+		sec := usageSection{title: "commands"}
+		for _, s := range f.commandNames() {
+			sec.rows = append(sec.rows,
+				usageRow{nil, s, f.commands[s].usage})
+		}
+		secs = append(secs, sec)
+	}
+	return secs
+}
+
+// inherits reports whether fl is reachable from f rather than shadowed by a
+// flag with the same name.
+func (f *FlagSet) inherits(fl *Flag) bool {
+	if s, ok := f.lookupShort(fl.Short); ok && s == fl {
+		return true
+	}
+	l, ok := f.lookupLong(fl.Long)
+	return ok && l == fl
+}
+
+// WriteUsage writes the usage text of f to w. It consists of the usage line,
+// the description, the flags and subcommands of f along with the flags that
+// it inherits, and the footer. Columns are aligned and text is wrapped to the
+// width described by SetWidth.
+func (f *FlagSet) WriteUsage(w io.Writer) error {
+	width := f.getWidth(w)
+	var b strings.Builder
+	b.WriteString("usage: " + f.getUsageLine() + "\n")
+	if f.description != "" {
+		b.WriteString("\n")
+		writeWrapped(&b, f.description, "", width)
+	}
+
+	secs := f.usageSections()
+	left := 0
+	for _, sec := range secs {
+		for _, row := range sec.rows {
+			n := utf8.RuneCountInString(row.left)
+			if n > left && n <= maxLeftWidth {
+				left = n
+			}
+		}
+	}
+	indent := strings.Repeat(" ", left+4)
+	for _, sec := range secs {
+		if len(sec.rows) == 0 {
+			continue
+		}
+		b.WriteString("\n" + sec.title + ":\n")
+		for _, row := range sec.rows {
+			n := utf8.RuneCountInString(row.left)
+			b.WriteString("  " + row.left)
+			if row.right == "" {
+				b.WriteString("\n")
+				continue
+			}
+			if n > left {
+				b.WriteString("\n" + indent)
+			} else {
+				b.WriteString(strings.Repeat(" ", left-n+2))
+			}
+			var d strings.Builder
+			writeWrapped(&d, row.right, indent, width)
+			b.WriteString(strings.TrimPrefix(d.String(), indent))
+		}
+	}
+
+	if f.footer != "" {
+		b.WriteString("\n")
+		writeWrapped(&b, f.footer, "", width)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteUsage writes the usage text of CommandLine to w. See
+// FlagSet.WriteUsage.
+func WriteUsage(w io.Writer) error {
+	return CommandLine.WriteUsage(w)
+}
+
+// writeWrapped writes the paragraphs of s to b with each line prefixed by
+// indent and wrapped to width. Paragraphs are separated by blank lines.
+func writeWrapped(b *strings.Builder, s, indent string, width int) {
+	for i, para := range strings.Split(strings.TrimSpace(s), "\n\n") {
+		if i != 0 {
+			b.WriteString("\n")
+		}
+		n := 0
+		for _, word := range strings.Fields(para) {
+			wn := utf8.RuneCountInString(word)
+			if n != 0 && n+1+wn > width {
+				b.WriteString("\n")
+				n = 0
+			}
+			if n == 0 {
+				b.WriteString(indent)
+				n = utf8.RuneCountInString(indent)
+			} else {
+				b.WriteString(" ")
+				n++
+			}
+			b.WriteString(word)
+			n += wn
+		}
+		b.WriteString("\n")
+	}
+}
+
+// PrintUsageAndExit writes the usage text of f to its output and exits with
+// status 1.
+func (f *FlagSet) PrintUsageAndExit() {
+	f.WriteUsage(f.Output())
+	osExit(1)
+}
+
+// PrintUsageAndExit writes the usage text of CommandLine to its output and
+// exits with status 1.
+func PrintUsageAndExit() {
+	CommandLine.PrintUsageAndExit()
+}
@@ -0,0 +1,113 @@
+package flag
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWriteUsage(t *testing.T) {
+	var b bool
+	var i int64
+	var s, out string
+	var d time.Duration
+	var tags []string
+	f := NewFlagSet("tool", ContinueOnError)
+	f.SetWidth(60)
+	f.SetDescription("Tool does things. It does so many things that they " +
+		"cannot all be listed on one line.\n\nIt is a tool.")
+	f.SetFooter("Report bugs to nobody.")
+	f.Bool(&b, 'b', "bool", false, "bool flag")
+	f.Int64(&i, 'i', "int", 0, "int flag")
+	f.String(&s, 0, "s-t-r", "x", "string flag").Env("TOOL_STR")
+	f.Group("output")
+	f.String(&out, 'o', "", "", "write to `FILE`")
+	f.StringSlice(&tags, 0, "a-really-long-flag-name-for-tags", nil,
+		"tags to apply to everything that is written, which wraps")
+	f.Group("")
+	f.Duration(&d, 'd', "", time.Second, "delay")
+	add := f.Command("add", "add things", nil)
+	f.Command("remove", "remove things", nil)
+	add.Int64(&i, 'n', "num", 3, "")
+
+	want := `usage: tool [flags] <command>
+
+Tool does things. It does so many things that they cannot
+all be listed on one line.
+
+It is a tool.
+
+flags:
+  -b, --[no-]bool     bool flag
+  -i, --int=N         int flag (default 0)
+      --s-t-r=STRING  string flag (default "x") [$TOOL_STR]
+  -d DURATION         delay (default 1s)
+  -h, --help          show this help and exit
+
+output:
+  -o FILE             write to FILE
+      --a-really-long-flag-name-for-tags=STRING
+                      tags to apply to everything that is
+                      written, which wraps
+
+commands:
+  add                 add things
+  remove              remove things
+
+Report bugs to nobody.
+`
+	for n := 0; n < 2; n++ {
+		var buf bytes.Buffer
+		if err := f.WriteUsage(&buf); err != nil || buf.String() != want {
+			t.Errorf("%s", buf.String())
+		}
+	}
+
+	want = `usage: tool add [flags]
+
+flags:
+  -n, --num=N         (default 3)
+  -h, --help          show this help and exit
+
+global flags:
+  -b, --[no-]bool     bool flag
+  -i, --int=N         int flag (default 0)
+      --s-t-r=STRING  string flag (default "x") [$TOOL_STR]
+  -o FILE             write to FILE
+      --a-really-long-flag-name-for-tags=STRING
+                      tags to apply to everything that is
+                      written, which wraps
+  -d DURATION         delay (default 1s)
+`
+	var buf bytes.Buffer
+	add.SetWidth(60)
+	add.WriteUsage(&buf)
+	if buf.String() != want {
+		t.Errorf("%s", buf.String())
+	}
+}
+
+func TestWidth(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "usage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	t.Setenv("COLUMNS", "")
+	f := NewFlagSet("test", ContinueOnError)
+	if w := terminalWidth(file); w != 0 {
+		t.Error(w)
+	}
+	if w := f.getWidth(file); w != defaultWidth {
+		t.Error(w)
+	}
+	t.Setenv("COLUMNS", "100")
+	if w := f.getWidth(file); w != 100 {
+		t.Error(w)
+	}
+	f.SetWidth(20)
+	if w := f.getWidth(file); w != minWidth {
+		t.Error(w)
+	}
+}
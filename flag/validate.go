@@ -0,0 +1,112 @@
+// Copyright 2018 iriri. All rights reserved. Use of this source code is
+// governed by a BSD-style license which can be found in the LICENSE file.
+
+package flag
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type choiceVal struct {
+	p       *string
+	choices []string
+}
+
+func (v *choiceVal) Set(s string) error {
+	for _, c := range v.choices {
+		if s == c {
+			*v.p = s
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of %s", strings.Join(v.choices, ", "))
+}
+
+func (v *choiceVal) String() string {
+	return *v.p
+}
+
+// Choice defines a string flag with the specified short and/or long variants,
+// allowed values, base value, and usage text. The argument val points to where
+// the value is stored. Giving the flag any value other than one of choices is
+// an error, as is a base value that is not one of choices. The choices are
+// listed in the usage text and offered by shell completion.
+func (f *FlagSet) Choice(
+	val *string,
+	short rune,
+	long string,
+	choices []string,
+	base string,
+	usage string) *Flag {
+	*val = base
+	v := &choiceVal{val, choices}
+	fl := f.define(v, short, long, usage)
+	if err := v.Set(base); err != nil {
+		err = &DefineError{fl.name(), invalidValue(base, err)}
+		if f.err == nil {
+			f.err = err
+		}
+		f.fail(err)
+	}
+	return fl
+}
+
+// Choice defines a choice flag on CommandLine. See FlagSet.Choice.
+func Choice(
+	val *string,
+	short rune,
+	long string,
+	choices []string,
+	base string,
+	usage string) *Flag {
+	return CommandLine.Choice(val, short, long, choices, base, usage)
+}
+
+// Validate adds a function that checks the values given for fl and returns
+// fl. Each time fl is set from the command line, the environment, or a config
+// file, the function is called with the text of the value before the value is
+// set. If it returns an error the flag is reported as having an invalid value
+// and is left unchanged. Validators are called in the order they were added,
+// and text that the flag cannot parse may be left for the flag to report.
+func (fl *Flag) Validate(fn func(s string) error) *Flag {
+	fl.validators = append(fl.validators, fn)
+	return fl
+}
+
+// validate returns the first error returned by the validators of fl.
+func (fl *Flag) validate(s string) error {
+	for _, fn := range fl.validators {
+		if err := fn(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IntRange returns a validator for integer flags that requires values to be
+// between min and max, inclusive. Values that are not integers are left for
+// the flag to report.
+func IntRange(min, max int64) func(string) error {
+	return func(s string) error {
+		n, err := strconv.ParseInt(decimal(s), 0, 64)
+		if errors.Is(err, strconv.ErrRange) ||
+			err == nil && (n < min || n > max) {
+			return fmt.Errorf("must be between %d and %d", min, max)
+		}
+		return nil
+	}
+}
+
+// Match returns a validator that requires values to match re.
+func Match(re *regexp.Regexp) func(string) error {
+	return func(s string) error {
+		if !re.MatchString(s) {
+			return fmt.Errorf("must match %s", re)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,114 @@
+package flag
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestChoice(t *testing.T) {
+	var format string
+	f := NewFlagSet("test", ContinueOnError)
+	f.Choice(&format, 'f', "format", []string{"text", "json"}, "text",
+		"output format")
+	if format != "text" {
+		t.Fail()
+	}
+	if _, err := f.Parse([]string{"-f", "json"}); err != nil ||
+		format != "json" {
+		t.Error(err)
+	}
+	_, err := f.Parse([]string{"--format=yaml"})
+	if !errors.Is(err, ErrInvalidValue) || err.Error() !=
+		`--format: invalid value "yaml": must be one of text, json` {
+		t.Error(err)
+	}
+	if format != "json" {
+		t.Fail()
+	}
+
+	var b bytes.Buffer
+	f.WriteUsage(&b)
+	if !strings.Contains(b.String(), "--format=STRING  output format "+
+		"(one of text, json) (default text)") {
+		t.Error(b.String())
+	}
+	if s := completeString(f, "--format", "j"); s != "json\n:\n" {
+		t.Error(s)
+	}
+	if s := completeString(f, "--format="); s !=
+		"--format=text\n--format=json\n:\n" {
+		t.Error(s)
+	}
+
+	f = NewFlagSet("test", ContinueOnError)
+	fl := f.Choice(&format, 0, "format", []string{"text", "json"}, "yaml", "")
+	var derr *DefineError
+	if err = f.err; !errors.As(err, &derr) || fl == nil || err.Error() !=
+		`--format: invalid value "yaml": must be one of text, json` {
+		t.Error(err)
+	}
+	if _, err = f.Parse(nil); err != derr {
+		t.Error(err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var n int64
+	var name string
+	f := NewFlagSet("test", ContinueOnError)
+	f.Int64(&n, 'n', "num", 1, "").Validate(IntRange(1, 10))
+	f.String(&name, 0, "name", "", "").Validate(
+		Match(regexp.MustCompile(`^[a-z]+$`)))
+	if _, err := f.Parse([]string{"-n", "0xa", "--name", "abc"}); err != nil ||
+		n != 10 || name != "abc" {
+		t.Error(err)
+	}
+
+	for _, c := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"-n", "11"}, `-n: invalid value "11": must be between 1 ` +
+			"and 10"},
+		{[]string{"--num=0"}, `--num: invalid value "0": must be between 1 ` +
+			"and 10"},
+		{[]string{"-n", "x"}, `-n: invalid value "x": invalid syntax`},
+		{[]string{"--name", "A1"}, `--name: invalid value "A1": must match ` +
+			"^[a-z]+$"},
+	} {
+		_, err := f.Parse(c.args)
+		var perr *ParseError
+		if !errors.As(err, &perr) || !errors.Is(err, ErrInvalidValue) ||
+			err.Error() != c.err {
+			t.Error(c.args, err)
+		}
+	}
+
+	if n != 10 || name != "abc" {
+		t.Error(n, name)
+	}
+	var l []string
+	f.StringSlice(&l, 'l', "", nil, "").Validate(
+		Match(regexp.MustCompile(`^[a-z]+$`)))
+	if _, err := f.Parse([]string{"-l", "a", "-l", "B"}); err == nil ||
+		len(l) != 1 || l[0] != "a" {
+		t.Error(l, err)
+	}
+	f.Uint64(new(uint64), 'u', "", 0, "").Validate(IntRange(0, 10))
+	if _, err := f.Parse([]string{"-u", "18446744073709551615"}); err == nil {
+		t.Fail()
+	}
+
+	os.Setenv("TEST_NUM", "20")
+	defer os.Unsetenv("TEST_NUM")
+	f = NewFlagSet("test", ContinueOnError)
+	f.Int64(&n, 'n', "num", 1, "").Validate(IntRange(1, 10))
+	f.SetEnvPrefix("TEST")
+	if _, err := f.Parse(nil); !errors.Is(err, ErrInvalidValue) {
+		t.Error(err)
+	}
+}